@@ -0,0 +1,73 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ColumnSelector は columns / dedupe.columns の 1 要素を解析したもの
+//
+//	"3"     … 3 列目（1オリジン）
+//	"3-7"   … 3〜7 列目
+//	"*"     … 全列
+//	"題目"  … ヘッダ名
+//	"!備考" … 除外（上記いずれの書式も可）
+type ColumnSelector struct {
+	Raw     string
+	Exclude bool
+	All     bool
+	From    int    // 1オリジン（単一列なら From == To）
+	To      int    // 1オリジン
+	Name    string // ヘッダ名（番号指定なら空）
+}
+
+// ParseColumnSelectors は列指定を解析する。番号は 1 以上、範囲は From <= To であること。
+func ParseColumnSelectors(items []string) ([]ColumnSelector, error) {
+	out := make([]ColumnSelector, 0, len(items))
+	for _, item := range items {
+		sel, err := parseColumnSelector(item)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, sel)
+	}
+	return out, nil
+}
+
+func parseColumnSelector(item string) (ColumnSelector, error) {
+	sel := ColumnSelector{Raw: item}
+	s := strings.TrimSpace(item)
+	if strings.HasPrefix(s, "!") {
+		sel.Exclude = true
+		s = strings.TrimSpace(s[1:])
+	}
+	if s == "" {
+		return sel, fmt.Errorf("empty column selector %q", item)
+	}
+	if s == "*" {
+		sel.All = true
+		return sel, nil
+	}
+	if n, err := strconv.Atoi(s); err == nil {
+		if n <= 0 {
+			return sel, fmt.Errorf("column %q must be a 1-origin positive integer", item)
+		}
+		sel.From, sel.To = n, n
+		return sel, nil
+	}
+	// "3-7"（両端が数値のときだけ範囲とみなす。"発行-年" のようなヘッダ名はそのまま）
+	if a, b, ok := strings.Cut(s, "-"); ok {
+		from, err1 := strconv.Atoi(strings.TrimSpace(a))
+		to, err2 := strconv.Atoi(strings.TrimSpace(b))
+		if err1 == nil && err2 == nil {
+			if from <= 0 || to < from {
+				return sel, fmt.Errorf("invalid column range %q", item)
+			}
+			sel.From, sel.To = from, to
+			return sel, nil
+		}
+	}
+	sel.Name = s
+	return sel, nil
+}
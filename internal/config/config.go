@@ -4,7 +4,9 @@ import (
 	"bytes"
 	"fmt"
 	"os"
+	"path/filepath"
 	"reflect"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -17,9 +19,9 @@ import (
 )
 
 type LogConfig struct {
-	Level  string `mapstructure:"level"`
-	Format string `mapstructure:"format"`
-	Output string `mapstructure:"output"`
+	Level  string `mapstructure:"level"  yaml:"level"`
+	Format string `mapstructure:"format" yaml:"format"`
+	Output string `mapstructure:"output" yaml:"output"`
 }
 
 // 文字列 or 配列を受け付けるための型
@@ -28,61 +30,174 @@ type MultiChars struct {
 }
 
 type NormalizeConfig struct {
+	UnicodeForm   string     `mapstructure:"unicode_form"         yaml:"unicode_form"`   // nfc|nfd|nfkc(既定)|nfkd|none
+	PreserveChars MultiChars `mapstructure:"preserve_chars"       yaml:"preserve_chars"` // Unicode 正規化の対象外にする文字（集合）
+
 	ToUpper            bool       `mapstructure:"to_upper"             yaml:"to_upper"`
 	ToLower            bool       `mapstructure:"to_lower"             yaml:"to_lower"`
 	HalfKanaToFull     bool       `mapstructure:"half_kana_to_full"    yaml:"half_kana_to_full"`
 	FullKanaToHalf     bool       `mapstructure:"full_kana_to_half"    yaml:"full_kana_to_half"`
 	FullDigitToHalf    bool       `mapstructure:"full_digit_to_half"   yaml:"full_digit_to_half"`
 	ParenNumToHalf     bool       `mapstructure:"paren_num_to_half"    yaml:"paren_num_to_half"`
+	NormalizeNumbers   bool       `mapstructure:"normalize_numbers"    yaml:"normalize_numbers"` // 二千二十五 → 2025, ⑫ → 12, 1,234 → 1234
 	DashToHyphen       bool       `mapstructure:"dash_to_hyphen"       yaml:"dash_to_hyphen"`
 	RemoveParens       bool       `mapstructure:"remove_parens"        yaml:"remove_parens"`
 	RemoveNonPrintable bool       `mapstructure:"remove_non_printable" yaml:"remove_non_printable"`
 	RemoveCRLFOnly     bool       `mapstructure:"remove_crlf_only"     yaml:"remove_crlf_only"`
 	RemoveHTML         bool       `mapstructure:"remove_html"          yaml:"remove_html"`
+	HTMLMode           string     `mapstructure:"html_mode"            yaml:"html_mode"`            // remove_html の方式: regex(既定) | tokenizer
+	DecodeHTMLEntities bool       `mapstructure:"decode_html_entities" yaml:"decode_html_entities"` // &amp; &nbsp; &#12354; などを復号
 	RemoveChars        MultiChars `mapstructure:"remove_chars"         yaml:"remove_chars"`
 
-	RemoveHTMLTags   []string `mapstructure:"remove_html_tags"     yaml:"remove_html_tags"`
-	RemoveSubstrings []string `mapstructure:"remove_substrings"    yaml:"remove_substrings"`
+	RemoveHTMLTags   []string      `mapstructure:"remove_html_tags"     yaml:"remove_html_tags"`
+	RemoveSubstrings []string      `mapstructure:"remove_substrings"    yaml:"remove_substrings"`
+	Replace          []ReplaceRule `mapstructure:"replace"              yaml:"replace"` // 正規表現置換（記述順）
+
+	Dictionaries []DictionaryConfig `mapstructure:"dictionaries"         yaml:"dictionaries"` // 表記ゆれ辞書（記述順）
 
-	// TrimSpaces        bool `mapstructure:"trim_spaces"          yaml:"trim_spaces"`
-	// TrimHyphens       bool `mapstructure:"trim_hyphens"         yaml:"trim_hyphens"`
-	// TrimUnderscores   bool `mapstructure:"trim_underscores"     yaml:"trim_underscores"`
-	// TrimChars         MultiChars `mapstructure:"trim_chars"           yaml:"trim_chars"`
+	// 空白ポリシー（前後の空白トリムは常に実行）
+	SpaceToASCII    bool       `mapstructure:"space_to_ascii"       yaml:"space_to_ascii"`   // Zs（全角空白・NBSP 等）→ 半角空白
+	CollapseSpaces  bool       `mapstructure:"collapse_spaces"      yaml:"collapse_spaces"`  // 連続する空白・タブを半角空白 1 つに
+	TrimHyphens     bool       `mapstructure:"trim_hyphens"         yaml:"trim_hyphens"`     // 前後のハイフン類を除去
+	TrimUnderscores bool       `mapstructure:"trim_underscores"     yaml:"trim_underscores"` // 前後のアンダースコアを除去
+	TrimChars       MultiChars `mapstructure:"trim_chars"           yaml:"trim_chars"`       // 前後から除去する文字（集合）
 
-	WriteBack bool `mapstructure:"write_back"           yaml:"write_back"`
+	WriteBack        bool `mapstructure:"write_back"           yaml:"write_back"`
+	AppendNormalized bool `mapstructure:"append_normalized"    yaml:"append_normalized"` // 正規化値を別列（<ヘッダ>_normalized）で出力
 
 	RemovePunctuation bool `mapstructure:"remove_punctuation"   yaml:"remove_punctuation"`
 	RemoveSymbols     bool `mapstructure:"remove_symbols"       yaml:"remove_symbols"`
 	RemoveEmoji       bool `mapstructure:"remove_emoji"         yaml:"remove_emoji"`
+
+	EmojiMode        string `mapstructure:"emoji_mode"           yaml:"emoji_mode"`        // remove(既定) | replace | name（CLDR 短縮名）
+	EmojiPlaceholder string `mapstructure:"emoji_placeholder"    yaml:"emoji_placeholder"` // replace 時の置換文字列（既定 "[emoji]"）
+
+	HiraganaToKatakana bool `mapstructure:"hiragana_to_katakana" yaml:"hiragana_to_katakana"` // がっこう → ガッコウ
+	KatakanaToHiragana bool `mapstructure:"katakana_to_hiragana" yaml:"katakana_to_hiragana"` // ガッコウ → がっこう（両方 true なら hiragana_to_katakana 優先）
+	FoldSmallKana      bool `mapstructure:"fold_small_kana"      yaml:"fold_small_kana"`      // ァ → ア, っ → つ
+	StripDakuten       bool `mapstructure:"strip_dakuten"        yaml:"strip_dakuten"`        // バ → ハ, パ → ハ
+
+	RomajiToKana    bool   `mapstructure:"romaji_to_kana"       yaml:"romaji_to_kana"`    // Yamada Taro → ヤマダ タロ
+	KanaToRomaji    bool   `mapstructure:"kana_to_romaji"       yaml:"kana_to_romaji"`    // ヤマダ タロウ → yamada taro
+	RomajiSystem    string `mapstructure:"romaji_system"        yaml:"romaji_system"`     // hepburn(既定) | kunrei
+	RomajiLongVowel string `mapstructure:"romaji_long_vowel"    yaml:"romaji_long_vowel"` // omit(既定) | macron | spelled
+
+	FoldKanjiVariants bool   `mapstructure:"fold_kanji_variants"  yaml:"fold_kanji_variants"` // 髙 → 高, 﨑 → 崎（異体字セレクタも除去）
+	KanjiVariantTable string `mapstructure:"kanji_variant_table"  yaml:"kanji_variant_table"` // 既定テーブルに重ねる TSV（異体字\t代表字）
+	RemoveIVS         bool   `mapstructure:"remove_ivs"           yaml:"remove_ivs"`          // 異体字セレクタ U+E0100–U+E01EF を除去
+
+	StripDiacritics   bool     `mapstructure:"strip_diacritics"     yaml:"strip_diacritics"`   // Müller → Muller, ß → ss（かなの濁点は残す）
+	DiacriticsScripts []string `mapstructure:"diacritics_scripts"   yaml:"diacritics_scripts"` // 対象の文字種: latin | greek | cyrillic（既定: すべて）
+
+	// 列の型（正規化工程の後に解釈して正規形にする）
+	Type       string `mapstructure:"type"                 yaml:"type"`        // date
+	OnError    string `mapstructure:"on_error"             yaml:"on_error"`    // 解釈できない値: keep(既定) | blank | report（error_report へ）
+	DateLayout string `mapstructure:"date_layout"          yaml:"date_layout"` // type: date の出力書式（Go レイアウト or iso8601。既定 2006-01-02）
+
+	AddressPrefecture string `mapstructure:"address_prefecture"   yaml:"address_prefecture"` // type: address の都道府県: keep(既定) | add | strip
+	AddressCityTable  string `mapstructure:"address_city_table"   yaml:"address_city_table"` // 既定の市区町村テーブルに重ねる TSV（市区町村\t都道府県）
+
+	CompanyLegalForm string `mapstructure:"company_legal_form"   yaml:"company_legal_form"` // type: company_name の法人格: canonical(既定) | strip
+
+	PhoneFormat      string `mapstructure:"phone_format"         yaml:"phone_format"`       // type: phone の出力: digits(既定) | hyphen | e164
+	PostalCodeFormat string `mapstructure:"postal_code_format"   yaml:"postal_code_format"` // type: postal_code の出力: digits(既定) | hyphen
+	EmailFoldGmail   bool   `mapstructure:"email_fold_gmail"     yaml:"email_fold_gmail"`   // type: email で Gmail の "." と "+タグ" を畳み込む
+
+	URLDropParams         []string `mapstructure:"url_drop_params"          yaml:"url_drop_params"`          // type: url で取り除くクエリパラメータ名（utm_* 等のパターン）
+	URLSortQuery          bool     `mapstructure:"url_sort_query"           yaml:"url_sort_query"`           // クエリをパラメータ名順に並べる
+	URLStripFragment      bool     `mapstructure:"url_strip_fragment"       yaml:"url_strip_fragment"`       // #以降を取り除く
+	URLStripWWW           bool     `mapstructure:"url_strip_www"            yaml:"url_strip_www"`            // ホスト先頭の www. を取り除く
+	URLStripTrailingSlash bool     `mapstructure:"url_strip_trailing_slash" yaml:"url_strip_trailing_slash"` // パス末尾の / を取り除く（ルートは残す）
+
+	// 実行する工程と順序（normalize.StepNames 参照）。空なら既定順で上記フラグの工程のみ実行
+	Steps []string `mapstructure:"steps"                yaml:"steps"`
+}
+
+// ReplaceRule は正規表現置換の 1 規則
+type ReplaceRule struct {
+	Pattern     string `mapstructure:"pattern"     yaml:"pattern"`
+	Replacement string `mapstructure:"replacement" yaml:"replacement"` // $1 / ${name} でキャプチャ参照
+	Flags       string `mapstructure:"flags"       yaml:"flags"`       // i|m|s|U の組合せ
+}
+
+// DictionaryConfig は表記ゆれ辞書ファイルの指定
+type DictionaryConfig struct {
+	Path  string `mapstructure:"path"  yaml:"path"`  // .tsv ならタブ区切り、それ以外は CSV（1列目=表記ゆれ, 2列目=正規形）
+	Match string `mapstructure:"match" yaml:"match"` // longest(既定: 最長一致の部分文字列) | token(語単位)
+}
+
+// ColumnRule は列ごとに正規化プロファイルを割り当てる
+type ColumnRule struct {
+	Column  string `mapstructure:"column"  yaml:"column"`  // 1オリジンの列番号 or ヘッダ名
+	Profile string `mapstructure:"profile" yaml:"profile"` // profiles のキー（空なら normalize を使う）
 }
 
 type DedupeConfig struct {
-	Enabled        bool   `mapstructure:"enabled"`          // 重複排除を有効化
-	Columns        []int  `mapstructure:"columns"`          // キー作成に使う列(1オリジン)。未指定なら Config.Columns
-	AppendKey      bool   `mapstructure:"append_key"`       // キー列を末尾に追加
-	ReplaceTarget  bool   `mapstructure:"replace_target"`   // columns先頭列をキーで置換
-	DropDuplicates bool   `mapstructure:"drop_duplicates"`  // 既出キーの行を出力しない
-	Keep           string `mapstructure:"keep"`             // first|last（DropDuplicates時の残し方）
-	OutputHeader   string `mapstructure:"output_header"`    // AppendKey時のヘッダ名
-	Delimiter      string `mapstructure:"delimiter"`        // 連結区切り
-	UseNormalized  bool   `mapstructure:"use_normalized"`   // キー生成に正規化後を使うか(既定true)
-	IgnoreEmptyKey bool   `mapstructure:"ignore_empty_key"` // ★追加：空キーはdrop対象外
+	Enabled        bool     `mapstructure:"enabled"          yaml:"enabled"`          // 重複排除を有効化
+	Columns        []string `mapstructure:"columns"        yaml:"columns"`            // キー作成に使う列（columns と同じ書式）。未指定なら Config.Columns
+	AppendKey      bool     `mapstructure:"append_key"       yaml:"append_key"`       // キー列を末尾に追加
+	ReplaceTarget  bool     `mapstructure:"replace_target"   yaml:"replace_target"`   // columns先頭列をキーで置換
+	DropDuplicates bool     `mapstructure:"drop_duplicates"  yaml:"drop_duplicates"`  // 既出キーの行を出力しない
+	Keep           string   `mapstructure:"keep"             yaml:"keep"`             // first|last（DropDuplicates時の残し方）
+	OutputHeader   string   `mapstructure:"output_header"    yaml:"output_header"`    // AppendKey時のヘッダ名
+	Delimiter      string   `mapstructure:"delimiter"        yaml:"delimiter"`        // 連結区切り
+	UseNormalized  bool     `mapstructure:"use_normalized"   yaml:"use_normalized"`   // キー生成に正規化後を使うか(既定true)
+	IgnoreEmptyKey bool     `mapstructure:"ignore_empty_key" yaml:"ignore_empty_key"` // ★追加：空キーはdrop対象外
+
+	// あいまい一致（mode: fuzzy）
+	Mode          string   `mapstructure:"mode"           yaml:"mode"`           // exact(既定: キー文字列の完全一致) | fuzzy(類似度でクラスタリング) | lsh(MinHash/LSH)
+	Similarity    string   `mapstructure:"similarity"     yaml:"similarity"`     // levenshtein(既定) | jaro_winkler | ngram
+	Threshold     float64  `mapstructure:"threshold"      yaml:"threshold"`      // 同一とみなす類似度の下限（0〜1。既定 0.9）
+	NGram         int      `mapstructure:"ngram"          yaml:"ngram"`          // similarity: ngram の文字数（既定 2）
+	Blocking      []string `mapstructure:"blocking"       yaml:"blocking"`       // 比較対象を絞るキー: prefix:N | suffix:N | token（既定 [prefix:3, suffix:3]）
+	ClusterHeader string   `mapstructure:"cluster_header" yaml:"cluster_header"` // クラスタ ID 列の見出し（fuzzy / lsh）
+
+	// 大規模向けの近似クラスタリング（mode: lsh）
+	ShingleSize int `mapstructure:"shingle_size" yaml:"shingle_size"` // MinHash に使う文字 shingle の長さ（既定 3）
+	LSHBands    int `mapstructure:"lsh_bands"    yaml:"lsh_bands"`    // 署名を分ける帯の数（既定 20）
+	LSHRows     int `mapstructure:"lsh_rows"     yaml:"lsh_rows"`     // 帯あたりのハッシュ数（既定 5）
+
+	// 一時ファイルを使う重複排除（mode: exact の drop_duplicates）
+	MemoryLimit string `mapstructure:"memory_limit" yaml:"memory_limit"` // キーをメモリに溜める上限（"512MB" など。空なら全行をメモリに保持）
+	SpillDir    string `mapstructure:"spill_dir"    yaml:"spill_dir"`    // 一時ファイルの置き場所（空ならOSの一時ディレクトリ）
+
+	VerifyKeys bool `mapstructure:"verify_keys" yaml:"verify_keys"` // keep=first のストリーミングでキー文字列も保持し、ハッシュ衝突を照合する
+
+	// 重複のレビュー用の列（キー列・クラスタ ID 列の後ろに追加）
+	AppendGroupID  bool   `mapstructure:"append_group_id"  yaml:"append_group_id"`  // 同じキー（fuzzy/lsh ではクラスタ）の行に共通の ID
+	GroupIDFormat  string `mapstructure:"group_id_format"  yaml:"group_id_format"`  // seq(既定: 出現順の番号) | hash(キーから決まる 16 桁の 16 進数)
+	AppendDupCount bool   `mapstructure:"append_dup_count" yaml:"append_dup_count"` // 同じキーの行数
+	AppendDupRank  bool   `mapstructure:"append_dup_rank"  yaml:"append_dup_rank"`  // グループ内の順位（keep で残す行が 1）
+	GroupIDHeader  string `mapstructure:"group_id_header"  yaml:"group_id_header"`
+	DupCountHeader string `mapstructure:"dup_count_header" yaml:"dup_count_header"`
+	DupRankHeader  string `mapstructure:"dup_rank_header"  yaml:"dup_rank_header"`
+}
+
+// NormalizedColumnsConfig は append_normalized で追加する列の名前と位置
+type NormalizedColumnsConfig struct {
+	Suffix   string `mapstructure:"suffix"   yaml:"suffix"`   // 列名の接尾辞（既定 "_normalized"）
+	Position string `mapstructure:"position" yaml:"position"` // end(既定: 末尾にまとめて) | after(元列の直後)
 }
 
 type OutputConfig struct {
-	LineEnding string `mapstructure:"line_ending"` // "crlf" | "lf" (default: "crlf")
-	UTF8BOM    bool   `mapstructure:"utf8_bom"`    // default: true（UTF-8 のときのみ有効）
+	LineEnding string `mapstructure:"line_ending" yaml:"line_ending"` // "crlf" | "lf" (default: "crlf")
+	UTF8BOM    bool   `mapstructure:"utf8_bom"    yaml:"utf8_bom"`    // default: true（UTF-8 のときのみ有効）
 }
 
 type Config struct {
-	Columns   []int           `mapstructure:"columns"`    // 正規化対象列(1オリジン)
-	CodePage  string          `mapstructure:"code_page"`  // cp932|utf8
-	HasHeader bool            `mapstructure:"has_header"` // 先頭行はヘッダ行か
-	Log       LogConfig       `mapstructure:"log"`
-	Normalize NormalizeConfig `mapstructure:"normalize"`
-	Dedupe    DedupeConfig    `mapstructure:"dedupe"`
-	Output    OutputConfig    `mapstructure:"output"`
-	Timeout   time.Duration   `mapstructure:"timeout"`
+	Columns   []string        `mapstructure:"columns"    yaml:"columns"`    // 正規化対象列（1オリジン番号/ヘッダ名/範囲/"*"/"!除外"）
+	CodePage  string          `mapstructure:"code_page"  yaml:"code_page"`  // cp932|utf8
+	HasHeader bool            `mapstructure:"has_header" yaml:"has_header"` // 先頭行はヘッダ行か
+	Log       LogConfig       `mapstructure:"log"        yaml:"log"`
+	Normalize NormalizeConfig `mapstructure:"normalize"  yaml:"normalize"`
+	Dedupe    DedupeConfig    `mapstructure:"dedupe"     yaml:"dedupe"`
+	Output    OutputConfig    `mapstructure:"output"     yaml:"output"`
+	Timeout   time.Duration   `mapstructure:"timeout"    yaml:"timeout"`
+
+	Profiles          map[string]NormalizeConfig `mapstructure:"profiles"           yaml:"profiles"`           // 名前付き正規化プロファイル
+	ColumnRules       []ColumnRule               `mapstructure:"column_rules"       yaml:"column_rules"`       // 列ごとのプロファイル割当て
+	NormalizedColumns NormalizedColumnsConfig    `mapstructure:"normalized_columns" yaml:"normalized_columns"` // append_normalized の列名・位置
+	ErrorReport       string                     `mapstructure:"error_report"       yaml:"error_report"`       // on_error: report の値を書き出す CSV（空ならログに警告）
 }
 
 func (m *MultiChars) UnmarshalYAML(n *yaml.Node) error {
@@ -108,7 +223,7 @@ func (m *MultiChars) UnmarshalYAML(n *yaml.Node) error {
 
 func defaultConfig() Config {
 	return Config{
-		Columns:   []int{1}, // 1オリジン
+		Columns:   []string{"1"}, // 1オリジン
 		CodePage:  "utf8",
 		HasHeader: false,
 		Log: LogConfig{
@@ -117,6 +232,7 @@ func defaultConfig() Config {
 			Output: "stdout",
 		},
 		Normalize: NormalizeConfig{
+			UnicodeForm:     "nfkc",
 			FullDigitToHalf: true,
 			DashToHyphen:    true,
 			WriteBack:       true,
@@ -131,6 +247,22 @@ func defaultConfig() Config {
 			OutputHeader:   "__dedupe_key",
 			UseNormalized:  true,
 			IgnoreEmptyKey: true, // ★既定で“空キーは落とさない”
+			Mode:           "exact",
+			Similarity:     "levenshtein",
+			Threshold:      0.9,
+			NGram:          2,
+			ClusterHeader:  "__cluster_id",
+			ShingleSize:    3,
+			LSHBands:       20,
+			LSHRows:        5,
+			GroupIDFormat:  "seq",
+			GroupIDHeader:  "__group_id",
+			DupCountHeader: "__dup_count",
+			DupRankHeader:  "__dup_rank",
+		},
+		NormalizedColumns: NormalizedColumnsConfig{
+			Suffix:   "_normalized",
+			Position: "end",
 		},
 		Output: OutputConfig{
 			LineEnding: "crlf",
@@ -183,6 +315,16 @@ func Load(cfgFile string, flags *pflag.FlagSet, noStrict bool) (Config, error) {
 		return Config{}, err
 	}
 
+	// 辞書・異体字テーブルの相対パスは設定ファイルの場所から解決
+	if cfgFile != "" {
+		base := filepath.Dir(cfgFile)
+		resolveNormalizePaths(base, &c.Normalize)
+		for name, p := range c.Profiles {
+			resolveNormalizePaths(base, &p)
+			c.Profiles[name] = p
+		}
+	}
+
 	// 3) CLI フラグで上書き（指定があった項目のみ）
 	if flags != nil {
 		if f := flags.Lookup("output.line_ending"); f != nil && f.Changed {
@@ -213,17 +355,46 @@ func Load(cfgFile string, flags *pflag.FlagSet, noStrict bool) (Config, error) {
 	if !strings.EqualFold(c.CodePage, "utf8") && !strings.EqualFold(c.CodePage, "cp932") {
 		return Config{}, fmt.Errorf("unsupported code_page: %s (use utf8 or cp932)", c.CodePage)
 	}
-	for _, x := range c.Columns {
-		if x <= 0 {
-			return Config{}, fmt.Errorf("columns must be 1-origin positive integers: %v", c.Columns)
-		}
+	if _, err := ParseColumnSelectors(c.Columns); err != nil {
+		return Config{}, fmt.Errorf("columns: %w", err)
 	}
-	if len(c.Dedupe.Columns) > 0 {
-		for _, x := range c.Dedupe.Columns {
-			if x <= 0 {
-				return Config{}, fmt.Errorf("dedupe.columns must be 1-origin positive integers: %v", c.Dedupe.Columns)
+	if _, err := ParseColumnSelectors(c.Dedupe.Columns); err != nil {
+		return Config{}, fmt.Errorf("dedupe.columns: %w", err)
+	}
+	if err := validateNormalize("normalize", c.Normalize); err != nil {
+		return Config{}, err
+	}
+	// 英語の語もローマ字として読めれば変換されるため、列ごとに明示したときだけ使える（steps での指定も同じ）
+	if c.Normalize.RomajiToKana || slices.ContainsFunc(c.Normalize.Steps, func(s string) bool {
+		return strings.EqualFold(strings.TrimSpace(s), "romaji_to_kana")
+	}) {
+		return Config{}, fmt.Errorf("normalize.romaji_to_kana: set it in a profile and assign the profile to name columns with column_rules")
+	}
+	// プロファイル名は Viper 経由だと小文字化されるため、常に小文字で扱う
+	if len(c.Profiles) > 0 {
+		profiles := make(map[string]NormalizeConfig, len(c.Profiles))
+		for name, p := range c.Profiles {
+			name = strings.ToLower(strings.TrimSpace(name))
+			if err := validateNormalize("profiles."+name, p); err != nil {
+				return Config{}, err
 			}
+			profiles[name] = p
 		}
+		c.Profiles = profiles
+	}
+	for i, r := range c.ColumnRules {
+		if strings.TrimSpace(r.Column) == "" {
+			return Config{}, fmt.Errorf("column_rules[%d]: column is required", i)
+		}
+		if _, err := c.Profile(r.Profile); err != nil {
+			return Config{}, fmt.Errorf("column_rules[%d]: %w", i, err)
+		}
+	}
+	switch strings.ToLower(c.NormalizedColumns.Position) {
+	case "after":
+		c.NormalizedColumns.Position = "after"
+	default:
+		c.NormalizedColumns.Position = "end"
 	}
 	// Keep 正規化
 	switch c.Dedupe.Keep {
@@ -233,10 +404,73 @@ func Load(cfgFile string, flags *pflag.FlagSet, noStrict bool) (Config, error) {
 	default:
 		c.Dedupe.Keep = "first"
 	}
+	if err := validateDedupe(&c.Dedupe); err != nil {
+		return Config{}, err
+	}
 
 	return c, nil
 }
 
+// Profile は名前付きプロファイルを返す。空名なら normalize（既定プロファイル）。
+func (c Config) Profile(name string) (NormalizeConfig, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" {
+		return c.Normalize, nil
+	}
+	p, ok := c.Profiles[name]
+	if !ok {
+		return NormalizeConfig{}, fmt.Errorf("unknown normalize profile: %q", name)
+	}
+	return p, nil
+}
+
+// validateNormalize は列挙値・置換パターン・steps（normalize.Options.Validate）と、
+// 辞書・テーブルのファイルの有無を早期に検証する
+func validateNormalize(prefix string, nc NormalizeConfig) error {
+	opts := nc.Options()
+	if err := opts.Validate(); err != nil {
+		return fmt.Errorf("%s: %w", prefix, err)
+	}
+	for i, d := range nc.Dictionaries {
+		if strings.TrimSpace(d.Path) == "" {
+			return fmt.Errorf("%s.dictionaries[%d]: path is required", prefix, i)
+		}
+		if _, err := os.Stat(d.Path); err != nil {
+			return fmt.Errorf("%s.dictionaries[%d]: %w", prefix, i, err)
+		}
+		switch strings.ToLower(d.Match) {
+		case "", "longest", "token":
+		default:
+			return fmt.Errorf("%s.dictionaries[%d]: unknown match %q (use longest or token)", prefix, i, d.Match)
+		}
+	}
+	if nc.KanjiVariantTable != "" {
+		if _, err := os.Stat(nc.KanjiVariantTable); err != nil {
+			return fmt.Errorf("%s.kanji_variant_table: %w", prefix, err)
+		}
+	}
+	if nc.AddressCityTable != "" {
+		if _, err := os.Stat(nc.AddressCityTable); err != nil {
+			return fmt.Errorf("%s.address_city_table: %w", prefix, err)
+		}
+	}
+	return nil
+}
+
+func resolveNormalizePaths(base string, nc *NormalizeConfig) {
+	if nc.KanjiVariantTable != "" && !filepath.IsAbs(nc.KanjiVariantTable) {
+		nc.KanjiVariantTable = filepath.Join(base, nc.KanjiVariantTable)
+	}
+	if nc.AddressCityTable != "" && !filepath.IsAbs(nc.AddressCityTable) {
+		nc.AddressCityTable = filepath.Join(base, nc.AddressCityTable)
+	}
+	for i, d := range nc.Dictionaries {
+		if d.Path != "" && !filepath.IsAbs(d.Path) {
+			nc.Dictionaries[i].Path = filepath.Join(base, d.Path)
+		}
+	}
+}
+
 func multiCharsDecodeHook() mapstructure.DecodeHookFunc {
 	return func(from, to reflect.Type, data interface{}) (interface{}, error) {
 		// from string → MultiChars
@@ -0,0 +1,212 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/pflag"
+	"github.com/yourorg/strcleaner/internal/normalize"
+)
+
+func TestLoadDefault(t *testing.T) {
+	c, err := Load("", pflag.NewFlagSet("test", pflag.ContinueOnError), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.CodePage != "utf8" {
+		t.Fatalf("default code page not utf8: %s", c.CodePage)
+	}
+}
+
+func TestEnvOverride(t *testing.T) {
+	os.Setenv("STRCLEANER_COLUMNS", "1,2")
+	defer os.Unsetenv("STRCLEANER_COLUMNS")
+	c, _ := Load("", pflag.NewFlagSet("test", pflag.ContinueOnError), false)
+	if len(c.Columns) == 0 || c.Columns[0] != "1" {
+		t.Fatalf("env override failed: %+v", c.Columns)
+	}
+}
+
+func TestLoadUnknownStep(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	yml := "normalize:\n  steps: [unicode_normalize, no_such_step]\n"
+	if err := os.WriteFile(path, []byte(yml), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(path, pflag.NewFlagSet("test", pflag.ContinueOnError), false); err == nil {
+		t.Fatal("want error for unknown normalize step")
+	}
+}
+
+func TestLoadColumnRules(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	yml := "profiles:\n  Title:\n    to_lower: true\ncolumn_rules:\n  - { column: 題目, profile: title }\n  - { column: 2 }\n"
+	if err := os.WriteFile(path, []byte(yml), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	c, err := Load(path, pflag.NewFlagSet("test", pflag.ContinueOnError), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(c.ColumnRules) != 2 || c.ColumnRules[0] != (ColumnRule{Column: "題目", Profile: "title"}) {
+		t.Fatalf("column_rules: %+v", c.ColumnRules)
+	}
+	title, err := c.Profile(c.ColumnRules[0].Profile)
+	if err != nil || !title.ToLower {
+		t.Fatalf("title: %+v (%v)", title, err)
+	}
+	// プロファイルは normalize の既定値を引き継がない
+	if title.UnicodeForm != "" || title.FullDigitToHalf || title.DashToHyphen || title.WriteBack {
+		t.Fatalf("profile inherited normalize defaults: %+v", title)
+	}
+	// profile が空の列は normalize を使う
+	if nc, err := c.Profile(c.ColumnRules[1].Profile); err != nil || nc.UnicodeForm != "nfkc" || !nc.FullDigitToHalf {
+		t.Fatalf("default: %+v (%v)", nc, err)
+	}
+
+	yml = "profiles:\n  Title:\n    to_lower: true\ncolumn_rules:\n  - { column: 題目, profile: title }\n  - { column: 2, profile: author }\n"
+	if err := os.WriteFile(path, []byte(yml), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(path, pflag.NewFlagSet("test", pflag.ContinueOnError), false); err == nil {
+		t.Fatal("want error for unknown profile")
+	}
+}
+
+func TestLoadInvalidNormalizeEnum(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	for _, yml := range []string{
+		"normalize:\n  html_mode: dom\n",
+		"normalize:\n  emoji_mode: delete\n",
+		"normalize:\n  diacritics_scripts: [arabic]\n",
+		"profiles:\n  name:\n    romaji_system: nihon\n",
+		"profiles:\n  url:\n    url_drop_params: [\"[\"]\n",
+	} {
+		if err := os.WriteFile(path, []byte(yml), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Load(path, pflag.NewFlagSet("test", pflag.ContinueOnError), false); err == nil {
+			t.Fatalf("want error for %q", yml)
+		}
+	}
+}
+
+func TestLoadRomajiProfileOnly(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	for _, yml := range []string{
+		"normalize:\n  romaji_to_kana: true\n",
+		"normalize:\n  steps: [unicode_normalize, romaji_to_kana, kana_to_romaji]\n",
+	} {
+		if err := os.WriteFile(path, []byte(yml), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Load(path, pflag.NewFlagSet("test", pflag.ContinueOnError), false); err == nil {
+			t.Fatalf("want error for %q", yml)
+		}
+	}
+	yml := "profiles:\n  person:\n    romaji_to_kana: true\ncolumn_rules:\n  - { column: 氏名, profile: person }\n"
+	if err := os.WriteFile(path, []byte(yml), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(path, pflag.NewFlagSet("test", pflag.ContinueOnError), false); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoadInvalidReplace(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	yml := "normalize:\n  replace:\n    - { pattern: \"([a-z]\", replacement: \"$1\" }\n"
+	if err := os.WriteFile(path, []byte(yml), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(path, pflag.NewFlagSet("test", pflag.ContinueOnError), false); err == nil {
+		t.Fatal("want error for invalid replace pattern")
+	}
+}
+
+func TestLoadDedupeFuzzy(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	yml := "dedupe:\n  mode: Fuzzy\n  similarity: jaro_winkler\n  threshold: 0.85\n  blocking: [\"prefix:2\", token]\n"
+	if err := os.WriteFile(path, []byte(yml), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	c, err := Load(path, pflag.NewFlagSet("test", pflag.ContinueOnError), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Dedupe.Mode != "fuzzy" || c.Dedupe.Threshold != 0.85 || c.Dedupe.NGram != 2 {
+		t.Fatalf("unexpected dedupe config: %+v", c.Dedupe)
+	}
+
+	for _, bad := range []string{
+		"dedupe:\n  blocking: [\"prefix:0\"]\n",
+		"dedupe:\n  threshold: 1.5\n",
+		"dedupe:\n  similarity: cosine\n",
+		"dedupe:\n  mode: lsh\n  lsh_rows: 0\n",
+		"dedupe:\n  group_id_format: uuid\n",
+		"dedupe:\n  memory_limit: 64MB\n  append_dup_count: true\n",
+	} {
+		if err := os.WriteFile(path, []byte(bad), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Load(path, pflag.NewFlagSet("test", pflag.ContinueOnError), false); err == nil {
+			t.Fatalf("want error for %q", bad)
+		}
+	}
+}
+
+func TestParseByteSize(t *testing.T) {
+	cases := map[string]int64{"": 0, "65536": 65536, "512MB": 512 << 20, "1.5GiB": 3 << 29, "64 k": 64 << 10}
+	for in, want := range cases {
+		if got, err := ParseByteSize(in); err != nil || got != want {
+			t.Fatalf("%q: want %d got %d (%v)", in, want, got, err)
+		}
+	}
+	for _, bad := range []string{"MB", "12TB", "-1"} {
+		if _, err := ParseByteSize(bad); err == nil {
+			t.Fatalf("%q: want error", bad)
+		}
+	}
+}
+
+// 既定の normalize（full_digit_to_half でカタカナも半角になる）と組み合わせても、かなの工程で表記がそろう
+func TestDefaultPipelineKana(t *testing.T) {
+	for _, c := range []struct {
+		set   func(*NormalizeConfig)
+		equal []string
+	}{
+		{func(nc *NormalizeConfig) { nc.HiraganaToKatakana = true }, []string{"がっこう", "ガッコウ", "ｶﾞｯｺｳ"}},
+		{func(nc *NormalizeConfig) { nc.KatakanaToHiragana = true }, []string{"がっこう", "ガッコウ", "ｶﾞｯｺｳ"}},
+		{func(nc *NormalizeConfig) { nc.FoldSmallKana, nc.StripDakuten = true, true }, []string{"ァバ", "アハ", "ｧﾊﾞ"}},
+	} {
+		nc := defaultConfig().Normalize
+		c.set(&nc)
+		opts := nc.Options()
+		if err := opts.Prepare(); err != nil {
+			t.Fatal(err)
+		}
+		want := normalize.Clean(c.equal[0], opts)
+		for _, in := range c.equal[1:] {
+			if got := normalize.Clean(in, opts); got != want {
+				t.Fatalf("%q: want %q got %q", in, want, got)
+			}
+		}
+	}
+}
+
+// 既定の normalize に重ねた人名プロファイルで、ローマ字・全角カナ・半角カナが同じキーになる
+func TestDefaultPipelineRomaji(t *testing.T) {
+	nc := defaultConfig().Normalize
+	nc.RomajiToKana, nc.KanaToRomaji, nc.ToLower = true, true, true
+	opts := nc.Options()
+	if err := opts.Prepare(); err != nil {
+		t.Fatal(err)
+	}
+	for _, in := range []string{"Yamada Taro", "ヤマダ タロウ", "ﾔﾏﾀﾞ ﾀﾛｳ", "やまだ たろう"} {
+		if got := normalize.Clean(in, opts); got != "yamada taro" {
+			t.Fatalf("%q: want %q got %q", in, "yamada taro", got)
+		}
+	}
+}
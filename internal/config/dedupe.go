@@ -0,0 +1,127 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// BlockingKey は dedupe.mode: fuzzy で比較対象を絞るブロッキングキーの指定。
+//
+//	"prefix:3" … キーの先頭 3 文字が同じ行どうしだけ比較
+//	"suffix:3" … 末尾 3 文字
+//	"token"    … 空白・区切り文字で分けた語のいずれかが同じ
+type BlockingKey struct {
+	Raw  string
+	Kind string // prefix | suffix | token
+	N    int    // prefix / suffix の文字数
+}
+
+// DefaultBlocking は blocking 未指定時のブロッキングキー
+var DefaultBlocking = []string{"prefix:3", "suffix:3"}
+
+// ParseBlockingKeys はブロッキングキーの指定を解析する。空なら DefaultBlocking。
+func ParseBlockingKeys(items []string) ([]BlockingKey, error) {
+	if len(items) == 0 {
+		items = DefaultBlocking
+	}
+	out := make([]BlockingKey, 0, len(items))
+	for _, item := range items {
+		kind, arg, hasArg := strings.Cut(strings.ToLower(strings.TrimSpace(item)), ":")
+		bk := BlockingKey{Raw: item, Kind: kind}
+		switch kind {
+		case "prefix", "suffix":
+			n, err := strconv.Atoi(strings.TrimSpace(arg))
+			if !hasArg || err != nil || n <= 0 {
+				return nil, fmt.Errorf("blocking key %q: want %s:N (N >= 1)", item, kind)
+			}
+			bk.N = n
+		case "token":
+			if hasArg {
+				return nil, fmt.Errorf("blocking key %q: token takes no argument", item)
+			}
+		default:
+			return nil, fmt.Errorf("unknown blocking key %q (use prefix:N, suffix:N or token)", item)
+		}
+		out = append(out, bk)
+	}
+	return out, nil
+}
+
+// ParseByteSize は "512MB" / "1.5GiB" / "65536" のようなサイズ指定をバイト数にする。
+// 単位は B, K(B), M(B), G(B)（KiB 等も可。いずれも 1024 倍）。空文字は 0。
+func ParseByteSize(s string) (int64, error) {
+	t := strings.ToUpper(strings.TrimSpace(s))
+	if t == "" {
+		return 0, nil
+	}
+	num := strings.TrimRight(t, "KMGIB ")
+	unit := strings.TrimSpace(t[len(num):])
+	mult := map[string]float64{
+		"": 1, "B": 1,
+		"K": 1 << 10, "KB": 1 << 10, "KIB": 1 << 10,
+		"M": 1 << 20, "MB": 1 << 20, "MIB": 1 << 20,
+		"G": 1 << 30, "GB": 1 << 30, "GIB": 1 << 30,
+	}[unit]
+	v, err := strconv.ParseFloat(strings.TrimSpace(num), 64)
+	if err != nil || mult == 0 || v < 0 {
+		return 0, fmt.Errorf("invalid size %q (e.g. 512MB, 1GB)", s)
+	}
+	return int64(v * mult), nil
+}
+
+// validateDedupe は dedupe の列挙値と数値の範囲を検証し、既定値を補う
+func validateDedupe(d *DedupeConfig) error {
+	d.Mode = strings.ToLower(strings.TrimSpace(d.Mode))
+	switch d.Mode {
+	case "":
+		d.Mode = "exact"
+	case "exact", "fuzzy", "lsh":
+	default:
+		return fmt.Errorf("dedupe.mode: unknown mode %q (use exact, fuzzy or lsh)", d.Mode)
+	}
+	d.Similarity = strings.ToLower(strings.TrimSpace(d.Similarity))
+	switch d.Similarity {
+	case "":
+		d.Similarity = "levenshtein"
+	case "levenshtein", "jaro_winkler", "ngram":
+	default:
+		return fmt.Errorf("dedupe.similarity: unknown similarity %q (use levenshtein, jaro_winkler or ngram)", d.Similarity)
+	}
+	if d.Threshold <= 0 || d.Threshold > 1 {
+		return fmt.Errorf("dedupe.threshold: %v out of range (0 < threshold <= 1)", d.Threshold)
+	}
+	if d.NGram <= 0 {
+		return fmt.Errorf("dedupe.ngram: %d must be >= 1", d.NGram)
+	}
+	for _, v := range []struct {
+		key string
+		n   int
+	}{{"shingle_size", d.ShingleSize}, {"lsh_bands", d.LSHBands}, {"lsh_rows", d.LSHRows}} {
+		if v.n <= 0 {
+			return fmt.Errorf("dedupe.%s: %d must be >= 1", v.key, v.n)
+		}
+	}
+	if _, err := ParseBlockingKeys(d.Blocking); err != nil {
+		return fmt.Errorf("dedupe.blocking: %w", err)
+	}
+	d.GroupIDFormat = strings.ToLower(strings.TrimSpace(d.GroupIDFormat))
+	switch d.GroupIDFormat {
+	case "":
+		d.GroupIDFormat = "seq"
+	case "seq", "hash":
+	default:
+		return fmt.Errorf("dedupe.group_id_format: unknown format %q (use seq or hash)", d.GroupIDFormat)
+	}
+	limit, err := ParseByteSize(d.MemoryLimit)
+	if err != nil {
+		return fmt.Errorf("dedupe.memory_limit: %w", err)
+	}
+	if limit > 0 && d.Mode != "exact" {
+		return fmt.Errorf("dedupe.memory_limit: not supported with mode %q (exact only)", d.Mode)
+	}
+	if limit > 0 && (d.AppendGroupID || d.AppendDupCount || d.AppendDupRank) {
+		return fmt.Errorf("dedupe.memory_limit: cannot be combined with append_group_id, append_dup_count or append_dup_rank")
+	}
+	return nil
+}
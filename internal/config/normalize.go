@@ -0,0 +1,96 @@
+package config
+
+import (
+	"strings"
+
+	"github.com/yourorg/strcleaner/internal/normalize"
+)
+
+// Options は正規化設定を normalize.Options に変換する。
+// 列挙値などの検証は normalize.Options.Validate に任せる（設定読込時と実行時で同じ判定になる）。
+func (nc NormalizeConfig) Options() normalize.Options {
+	replace := make([]normalize.ReplaceRule, 0, len(nc.Replace))
+	for _, r := range nc.Replace {
+		replace = append(replace, normalize.ReplaceRule{Pattern: r.Pattern, Replacement: r.Replacement, Flags: r.Flags})
+	}
+
+	dicts := make([]normalize.DictionaryFile, 0, len(nc.Dictionaries))
+	for _, d := range nc.Dictionaries {
+		dicts = append(dicts, normalize.DictionaryFile{Path: d.Path, Token: strings.EqualFold(d.Match, "token")})
+	}
+
+	return normalize.Options{
+		UnicodeForm:   nc.UnicodeForm,
+		PreserveChars: strings.Join(nc.PreserveChars.Items, ""),
+
+		ToUpper:            nc.ToUpper,
+		ToLower:            nc.ToLower,
+		HalfKanaToFull:     nc.HalfKanaToFull,
+		FullKanaToHalf:     nc.FullKanaToHalf,
+		FullDigitToHalf:    nc.FullDigitToHalf,
+		ParenNumToHalf:     nc.ParenNumToHalf,
+		NormalizeNumbers:   nc.NormalizeNumbers,
+		DashToHyphen:       nc.DashToHyphen,
+		RemoveParens:       nc.RemoveParens,
+		RemoveNonPrintable: nc.RemoveNonPrintable,
+		RemoveHTML:         nc.RemoveHTML,
+		HTMLMode:           nc.HTMLMode,
+		DecodeHTMLEntities: nc.DecodeHTMLEntities,
+		RemoveChars:        "",
+		RemoveCharsList:    nc.RemoveChars.Items,
+
+		RemoveHTMLTags:   nc.RemoveHTMLTags,
+		RemoveSubstrings: nc.RemoveSubstrings,
+		Replace:          replace,
+		Dictionaries:     dicts,
+
+		SpaceToASCII:    nc.SpaceToASCII,
+		CollapseSpaces:  nc.CollapseSpaces,
+		TrimChars:       strings.Join(nc.TrimChars.Items, ""),
+		TrimHyphens:     nc.TrimHyphens,
+		TrimUnderscores: nc.TrimUnderscores,
+
+		RemoveCRLFOnly:    nc.RemoveCRLFOnly,
+		RemovePunctuation: nc.RemovePunctuation,
+		RemoveSymbols:     nc.RemoveSymbols,
+		RemoveEmoji:       nc.RemoveEmoji,
+		EmojiMode:         nc.EmojiMode,
+		EmojiPlaceholder:  nc.EmojiPlaceholder,
+
+		HiraganaToKatakana: nc.HiraganaToKatakana,
+		KatakanaToHiragana: nc.KatakanaToHiragana,
+		FoldSmallKana:      nc.FoldSmallKana,
+		StripDakuten:       nc.StripDakuten,
+
+		RomajiToKana:    nc.RomajiToKana,
+		KanaToRomaji:    nc.KanaToRomaji,
+		RomajiSystem:    nc.RomajiSystem,
+		RomajiLongVowel: nc.RomajiLongVowel,
+
+		FoldKanjiVariants: nc.FoldKanjiVariants,
+		KanjiVariantTable: nc.KanjiVariantTable,
+		RemoveIVS:         nc.RemoveIVS,
+
+		StripDiacritics:   nc.StripDiacritics,
+		DiacriticsScripts: nc.DiacriticsScripts,
+
+		Type:       nc.Type,
+		OnError:    nc.OnError,
+		DateLayout: nc.DateLayout,
+
+		AddressPrefecture: nc.AddressPrefecture,
+		AddressCityTable:  nc.AddressCityTable,
+		CompanyLegalForm:  nc.CompanyLegalForm,
+		PhoneFormat:       nc.PhoneFormat,
+		PostalCodeFormat:  nc.PostalCodeFormat,
+		EmailFoldGmail:    nc.EmailFoldGmail,
+
+		URLDropParams:         nc.URLDropParams,
+		URLSortQuery:          nc.URLSortQuery,
+		URLStripFragment:      nc.URLStripFragment,
+		URLStripWWW:           nc.URLStripWWW,
+		URLStripTrailingSlash: nc.URLStripTrailingSlash,
+
+		Steps: nc.Steps,
+	}
+}
@@ -0,0 +1,327 @@
+package csvproc
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/yourorg/strcleaner/internal/config"
+	"github.com/yourorg/strcleaner/internal/normalize"
+)
+
+// columnPlan は 1 列分の正規化設定
+type columnPlan struct {
+	opts             normalize.Options
+	writeBack        bool
+	appendNormalized bool
+}
+
+// rowPlan はヘッダ解決後の列ごとの処理計画
+type rowPlan struct {
+	targets    []int               // 正規化対象列（0オリジン）
+	columns    map[int]*columnPlan // column_rules で個別指定された列
+	base       *columnPlan         // 個別指定のない列（normalize）
+	dedupeCols []int               // キー作成に使う列（0オリジン）
+
+	appendCols []int  // 正規化値を別列で出力する列（0オリジン）
+	suffix     string // 追加列のヘッダ接尾辞
+	after      bool   // true: 元列の直後 / false: 末尾にまとめて
+
+	report func(col int, e *normalize.ValueError) // on_error: report の列で解釈できなかった値の通知先
+}
+
+// buildPlan は列指定をヘッダ（なければ列数 width）に対して解決する
+func buildPlan(conf config.Config, header []string, width int) (*rowPlan, error) {
+	base, err := newColumnPlan(conf.Normalize)
+	if err != nil {
+		return nil, err
+	}
+	p := &rowPlan{
+		columns: map[int]*columnPlan{},
+		base:    base,
+		suffix:  conf.NormalizedColumns.Suffix,
+		after:   conf.NormalizedColumns.Position == "after",
+	}
+
+	seen := map[int]bool{}
+	addTarget := func(col int) {
+		if !seen[col] {
+			seen[col] = true
+			p.targets = append(p.targets, col)
+		}
+	}
+
+	cols, err := resolveColumns(conf.Columns, header, width)
+	if err != nil {
+		return nil, fmt.Errorf("columns: %w", err)
+	}
+	for _, c := range cols {
+		addTarget(c)
+	}
+
+	// 列ごとのプロファイル（対象列にも加える）
+	for i, rule := range conf.ColumnRules {
+		col, err := resolveColumn(rule.Column, header)
+		if err != nil {
+			return nil, fmt.Errorf("column_rules[%d]: %w", i, err)
+		}
+		nc, err := conf.Profile(rule.Profile)
+		if err != nil {
+			return nil, fmt.Errorf("column_rules[%d]: %w", i, err)
+		}
+		cp, err := newColumnPlan(nc)
+		if err != nil {
+			return nil, fmt.Errorf("column_rules[%d]: %w", i, err)
+		}
+		p.columns[col] = cp
+		addTarget(col)
+	}
+
+	for _, col := range p.targets {
+		if p.planFor(col).appendNormalized {
+			p.appendCols = append(p.appendCols, col)
+		}
+	}
+
+	p.dedupeCols = p.targets
+	if len(conf.Dedupe.Columns) > 0 {
+		p.dedupeCols, err = resolveColumns(conf.Dedupe.Columns, header, width)
+		if err != nil {
+			return nil, fmt.Errorf("dedupe.columns: %w", err)
+		}
+	}
+	return p, nil
+}
+
+func newColumnPlan(nc config.NormalizeConfig) (*columnPlan, error) {
+	opts := nc.Options()
+	if err := opts.Prepare(); err != nil {
+		return nil, err
+	}
+	return &columnPlan{opts: opts, writeBack: nc.WriteBack, appendNormalized: nc.AppendNormalized}, nil
+}
+
+// planFor は列に適用する正規化設定を返す
+func (p *rowPlan) planFor(col int) *columnPlan {
+	if cp, ok := p.columns[col]; ok {
+		return cp
+	}
+	return p.base
+}
+
+// normalizeRow は対象列を正規化して列→正規化後の値を返す（write_back の列は rec を上書き）
+func (p *rowPlan) normalizeRow(rec []string) map[int]string {
+	normalized := make(map[int]string, len(p.targets))
+	for _, col := range p.targets {
+		if col >= 0 && col < len(rec) {
+			cp := p.planFor(col)
+			cleaned, err := normalize.CleanValue(rec[col], cp.opts)
+			var ve *normalize.ValueError
+			if errors.As(err, &ve) && p.report != nil && strings.EqualFold(cp.opts.OnError, "report") {
+				p.report(col, ve)
+			}
+			normalized[col] = cleaned
+			if cp.writeBack {
+				rec[col] = cleaned
+			}
+		}
+	}
+	return normalized
+}
+
+// expand は append_normalized の列（正規化値）を差し込んだ出力行を返す
+func (p *rowPlan) expand(rec []string, normalized map[int]string) []string {
+	return p.insert(rec, func(col int) string { return normalized[col] })
+}
+
+// expandHeader は append_normalized の列名（元ヘッダ名＋接尾辞）を差し込んだヘッダを返す
+func (p *rowPlan) expandHeader(header []string) []string {
+	return p.insert(header, func(col int) string {
+		if col < len(header) {
+			return headerName(header[col], col) + p.suffix
+		}
+		return strconv.Itoa(col+1) + p.suffix
+	})
+}
+
+func (p *rowPlan) insert(rec []string, value func(col int) string) []string {
+	if len(p.appendCols) == 0 {
+		return rec
+	}
+	out := make([]string, 0, len(rec)+len(p.appendCols))
+	if !p.after {
+		out = append(out, rec...)
+		for _, col := range p.appendCols {
+			out = append(out, value(col))
+		}
+		return out
+	}
+
+	extra := make(map[int]bool, len(p.appendCols))
+	for _, col := range p.appendCols {
+		extra[col] = true
+	}
+	for i, v := range rec {
+		out = append(out, v)
+		if extra[i] {
+			out = append(out, value(i))
+		}
+	}
+	// 行が短く元列が存在しない場合は末尾に追加
+	for _, col := range p.appendCols {
+		if col >= len(rec) {
+			out = append(out, value(col))
+		}
+	}
+	return out
+}
+
+// dedupeKey は dedupe 列の値を sep で連結したキーを返す（各列は自身のプロファイルで正規化）
+func (p *rowPlan) dedupeKey(rec []string, normalized map[int]string, useNormalized bool, sep string) string {
+	values := make([]string, 0, len(p.dedupeCols))
+	for _, col := range p.dedupeCols {
+		v := ""
+		if useNormalized {
+			if n, ok := normalized[col]; ok {
+				v = n
+			} else if col >= 0 && col < len(rec) {
+				v, _ = normalize.CleanValue(rec[col], p.planFor(col).opts)
+			}
+		} else if col >= 0 && col < len(rec) {
+			v = rec[col]
+		}
+		values = append(values, v)
+	}
+	return strings.Join(values, sep)
+}
+
+// needsWidth は列指定の解決に列数が必要か（"*" や除外のみの指定）
+func needsWidth(conf config.Config) bool {
+	for _, items := range [][]string{conf.Columns, conf.Dedupe.Columns} {
+		sels, err := config.ParseColumnSelectors(items)
+		if err != nil || len(sels) == 0 {
+			continue
+		}
+		include := false
+		for _, sel := range sels {
+			if sel.All {
+				return true
+			}
+			if !sel.Exclude {
+				include = true
+			}
+		}
+		if !include {
+			return true
+		}
+	}
+	return false
+}
+
+// resolveColumns は列指定を 0オリジンの列番号（重複なし・指定順）に変換する。
+// 除外だけが指定された場合は全列から除外する。
+func resolveColumns(items []string, header []string, width int) ([]int, error) {
+	sels, err := config.ParseColumnSelectors(items)
+	if err != nil {
+		return nil, err
+	}
+	if header != nil {
+		width = len(header)
+	}
+
+	var cols []int
+	seen := map[int]bool{}
+	excluded := map[int]bool{}
+	include := false
+	for _, sel := range sels {
+		if !sel.Exclude {
+			include = true
+		}
+		idx, err := selectorColumns(sel, header, width)
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range idx {
+			if sel.Exclude {
+				excluded[c] = true
+			} else if !seen[c] {
+				seen[c] = true
+				cols = append(cols, c)
+			}
+		}
+	}
+	if !include && len(sels) > 0 {
+		for c := 0; c < width; c++ {
+			cols = append(cols, c)
+		}
+	}
+
+	out := cols[:0]
+	for _, c := range cols {
+		if !excluded[c] {
+			out = append(out, c)
+		}
+	}
+	return out, nil
+}
+
+func selectorColumns(sel config.ColumnSelector, header []string, width int) ([]int, error) {
+	switch {
+	case sel.All:
+		cols := make([]int, 0, width)
+		for c := 0; c < width; c++ {
+			cols = append(cols, c)
+		}
+		return cols, nil
+	case sel.Name != "":
+		c, err := lookupHeader(sel.Name, header)
+		if err != nil {
+			return nil, err
+		}
+		return []int{c}, nil
+	default:
+		cols := make([]int, 0, sel.To-sel.From+1)
+		for c := sel.From; c <= sel.To; c++ {
+			cols = append(cols, c-1)
+		}
+		return cols, nil
+	}
+}
+
+// resolveColumn は 1オリジンの列番号またはヘッダ名（単一列）を 0オリジンの列番号に変換する
+func resolveColumn(ref string, header []string) (int, error) {
+	sels, err := config.ParseColumnSelectors([]string{ref})
+	if err != nil {
+		return 0, err
+	}
+	sel := sels[0]
+	switch {
+	case sel.Exclude || sel.All || sel.From != sel.To:
+		return 0, fmt.Errorf("column %q: a single column is required", ref)
+	case sel.Name != "":
+		return lookupHeader(sel.Name, header)
+	default:
+		return sel.From - 1, nil
+	}
+}
+
+func lookupHeader(name string, header []string) (int, error) {
+	if header == nil {
+		return 0, fmt.Errorf("column %q: header name requires has_header: true", name)
+	}
+	for i, h := range header {
+		if headerName(h, i) == name {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("column %q not found in header %q", name, header)
+}
+
+// headerName は比較用のヘッダ名（先頭列の UTF-8 BOM を除去）
+func headerName(h string, i int) string {
+	if i == 0 {
+		h = strings.TrimPrefix(h, "\ufeff")
+	}
+	return strings.TrimSpace(h)
+}
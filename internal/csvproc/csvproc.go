@@ -4,6 +4,8 @@ import (
 	"encoding/csv"
 	"io"
 	"os"
+	"runtime"
+	"strconv"
 	"strings"
 
 	"github.com/yourorg/strcleaner/internal/config"
@@ -24,8 +26,9 @@ func Process(inFile, outFile string, conf config.Config, log logging.Logger) err
 	if strings.EqualFold(conf.CodePage, "cp932") {
 		reader = transform.NewReader(in, japanese.ShiftJIS.NewDecoder())
 	}
-	r := csv.NewReader(reader)
-	r.LazyQuotes = true
+	cr := csv.NewReader(reader)
+	cr.LazyQuotes = true
+	r := &recordReader{Reader: cr, log: log}
 
 	var out io.Writer = os.Stdout
 
@@ -57,47 +60,6 @@ func Process(inFile, outFile string, conf config.Config, log logging.Logger) err
 
 	w.UseCRLF = strings.EqualFold(conf.Output.LineEnding, "crlf")
 
-	opts := normalize.Options{
-		ToUpper:            conf.Normalize.ToUpper,
-		ToLower:            conf.Normalize.ToLower,
-		HalfKanaToFull:     conf.Normalize.HalfKanaToFull,
-		FullKanaToHalf:     conf.Normalize.FullKanaToHalf,
-		FullDigitToHalf:    conf.Normalize.FullDigitToHalf,
-		ParenNumToHalf:     conf.Normalize.ParenNumToHalf,
-		DashToHyphen:       conf.Normalize.DashToHyphen,
-		RemoveParens:       conf.Normalize.RemoveParens,
-		RemoveNonPrintable: conf.Normalize.RemoveNonPrintable,
-		RemoveHTML:         conf.Normalize.RemoveHTML,
-		RemoveChars:        "",
-		RemoveCharsList:    conf.Normalize.RemoveChars.Items,
-
-		RemoveHTMLTags:   conf.Normalize.RemoveHTMLTags,
-		RemoveSubstrings: conf.Normalize.RemoveSubstrings,
-
-		RemoveCRLFOnly:    conf.Normalize.RemoveCRLFOnly,
-		RemovePunctuation: conf.Normalize.RemovePunctuation,
-		RemoveSymbols:     conf.Normalize.RemoveSymbols,
-		RemoveEmoji:       conf.Normalize.RemoveEmoji,
-	}
-
-	opts.Prepare()
-
-	// 1→0 変換
-	toZero := func(cols []int) []int {
-		z := make([]int, 0, len(cols))
-		for _, c := range cols {
-			if c > 0 {
-				z = append(z, c-1)
-			}
-		}
-		return z
-	}
-	targetCols := toZero(conf.Columns)
-
-	dedupeCols := targetCols
-	if len(conf.Dedupe.Columns) > 0 {
-		dedupeCols = toZero(conf.Dedupe.Columns)
-	}
 	sep := conf.Dedupe.Delimiter
 	if sep == "" {
 		sep = "|"
@@ -106,10 +68,7 @@ func Process(inFile, outFile string, conf config.Config, log logging.Logger) err
 	// --- デバッグ用カウンタ（-v 時に出力） ---
 	var total, wrote, dropped, emptyKey int
 
-	// --- ストリーミング書き出し路線か？ ---
-	streamMode := !conf.Dedupe.Enabled || !conf.Dedupe.DropDuplicates || len(dedupeCols) == 0
-
-	// ヘッダ処理
+	// ヘッダ処理（列ごとの設定はヘッダ名で解決するため先に読む）
 	var header []string
 	if conf.HasHeader {
 		rec, err := r.Read()
@@ -122,16 +81,55 @@ func Process(inFile, outFile string, conf config.Config, log logging.Logger) err
 			return err
 		}
 		header = append([]string{}, rec...)
-		if conf.Dedupe.Enabled && conf.Dedupe.AppendKey {
-			header = append(header, conf.Dedupe.OutputHeader)
+	}
+
+	// ヘッダなしで "*" や除外指定を使う場合は、先頭行を先読みして列数を決める
+	width := len(header)
+	if header == nil && needsWidth(conf) {
+		rec, _ := r.peek() // 空なら io.EOF で列数 0
+		width = len(rec)
+	}
+
+	plan, err := buildPlan(conf, header, width)
+	if err != nil {
+		return err
+	}
+
+	rep, err := openErrorReport(conf.ErrorReport, log)
+	if err != nil {
+		return err
+	}
+	defer rep.Close()
+	plan.report = func(col int, e *normalize.ValueError) {
+		name := strconv.Itoa(col + 1)
+		if col < len(header) {
+			name = headerName(header[col], col)
 		}
-		if err := w.Write(header); err != nil {
+		rep.add(total, name, e)
+	}
+
+	if header != nil {
+		if err := w.Write(outputHeader(header, plan, conf)); err != nil {
 			return err
 		}
 		wrote++
 	}
 
+	// --- ストリーミング書き出し路線か？（クラスタリングは全行のキーが揃うまで書き出せない） ---
+	clustered := conf.Dedupe.Mode == "fuzzy" || conf.Dedupe.Mode == "lsh"
+	limit, _ := config.ParseByteSize(conf.Dedupe.MemoryLimit)
+	withKey := conf.Dedupe.Enabled && len(plan.dedupeCols) > 0
+	// グループ ID・件数・順位の列も、同じキーの行がすべて揃ってから決まる
+	grouped := conf.Dedupe.AppendGroupID || conf.Dedupe.AppendDupCount || conf.Dedupe.AppendDupRank
+	dropFirst := withKey && conf.Dedupe.DropDuplicates && conf.Dedupe.Keep == "first" && !clustered && !grouped && limit == 0
+	streamMode := !withKey || !conf.Dedupe.DropDuplicates && !clustered && !grouped || dropFirst
+
 	if streamMode {
+		// keep=first は既出キーのハッシュだけを覚えて、行をそのまま書き出す
+		var filter *keyFilter
+		if dropFirst {
+			filter = newKeyFilter(conf.Dedupe.VerifyKeys)
+		}
 		// ====== ストリーミング書き出し（ここなら「ヘッダだけ」には絶対ならない） ======
 		for {
 			rec, err := r.Read()
@@ -145,46 +143,29 @@ func Process(inFile, outFile string, conf config.Config, log logging.Logger) err
 			total++
 
 			// 正規化（行内キャッシュ）
-			normalized := make(map[int]string)
-			for _, col := range targetCols {
-				if col >= 0 && col < len(rec) {
-					cleaned := normalize.Clean(rec[col], opts)
-					normalized[col] = cleaned
-					if conf.Normalize.WriteBack {
-						rec[col] = cleaned
-					}
-				}
-			}
+			normalized := plan.normalizeRow(rec)
 
 			// キー生成（append/replace用）
-			if conf.Dedupe.Enabled && len(dedupeCols) > 0 {
-				values := make([]string, 0, len(dedupeCols))
-				for _, col := range dedupeCols {
-					v := ""
-					if conf.Dedupe.UseNormalized {
-						if n, ok := normalized[col]; ok {
-							v = n
-						} else if col >= 0 && col < len(rec) {
-							v = normalize.Clean(rec[col], opts)
-						}
-					} else if col >= 0 && col < len(rec) {
-						v = rec[col]
-					}
-					values = append(values, v)
-				}
-				key := strings.Join(values, sep)
+			key := ""
+			if withKey {
+				key = plan.dedupeKey(rec, normalized, conf.Dedupe.UseNormalized, sep)
 				if strings.TrimSpace(key) == "" {
 					emptyKey++
+				} else if filter != nil && filter.seen(key) {
+					dropped++
+					continue
 				}
-				if conf.Dedupe.ReplaceTarget && len(dedupeCols) > 0 {
-					firstCol := dedupeCols[0]
+				if conf.Dedupe.ReplaceTarget && len(plan.dedupeCols) > 0 {
+					firstCol := plan.dedupeCols[0]
 					if firstCol >= 0 && firstCol < len(rec) {
 						rec[firstCol] = key
 					}
 				}
-				if conf.Dedupe.AppendKey {
-					rec = append(rec, key)
-				}
+			}
+
+			rec = plan.expand(rec, normalized)
+			if withKey && conf.Dedupe.AppendKey {
+				rec = append(rec, key)
 			}
 
 			if err := w.Write(rec); err != nil {
@@ -197,11 +178,18 @@ func Process(inFile, outFile string, conf config.Config, log logging.Logger) err
 		if err := w.Error(); err != nil {
 			return err
 		}
-		log.Debugf("stream: rows_read=%d wrote=%d empty_keys=%d", total, wrote, emptyKey)
-		return nil
+		if filter != nil {
+			var ms runtime.MemStats
+			runtime.ReadMemStats(&ms)
+			log.Debugf("dedupe(stream): rows_read=%d wrote=%d dropped=%d empty_keys=%d type_errors=%d keep=first verify_keys=%t seen_keys=%d key_mem=%dKiB heap=%dKiB",
+				total, wrote, dropped, emptyKey, rep.count, conf.Dedupe.VerifyKeys, filter.len(), filter.memBytes()>>10, ms.HeapAlloc>>10)
+			return rep.Close()
+		}
+		log.Debugf("stream: rows_read=%d wrote=%d empty_keys=%d type_errors=%d", total, wrote, emptyKey, rep.count)
+		return rep.Close()
 	}
 
-	// ====== ここからは drop_duplicates: true かつ dedupe 有効時（keep=first/last） ======
+	// ====== ここからは drop_duplicates: true、mode: fuzzy/lsh、append_group_id などの列（keep=first/last） ======
 	type row struct {
 		fields    []string
 		key       string
@@ -209,6 +197,16 @@ func Process(inFile, outFile string, conf config.Config, log logging.Logger) err
 	}
 	var rows []row
 
+	// memory_limit 指定時は行とキーを一時ファイルに退避する（mode: exact のみ）
+	var spill *spillDedupe
+	if limit > 0 && !clustered {
+		spill, err = newSpillDedupe(conf.Dedupe.SpillDir, limit, conf.Dedupe.Keep == "last")
+		if err != nil {
+			return err
+		}
+		defer spill.Close()
+	}
+
 	for {
 		rec, err := r.Read()
 		if err == io.EOF {
@@ -220,32 +218,8 @@ func Process(inFile, outFile string, conf config.Config, log logging.Logger) err
 		}
 		total++
 
-		normalized := make(map[int]string)
-		for _, col := range targetCols {
-			if col >= 0 && col < len(rec) {
-				cleaned := normalize.Clean(rec[col], opts)
-				normalized[col] = cleaned
-				if conf.Normalize.WriteBack {
-					rec[col] = cleaned
-				}
-			}
-		}
-
-		values := make([]string, 0, len(dedupeCols))
-		for _, col := range dedupeCols {
-			v := ""
-			if conf.Dedupe.UseNormalized {
-				if n, ok := normalized[col]; ok {
-					v = n
-				} else if col >= 0 && col < len(rec) {
-					v = normalize.Clean(rec[col], opts)
-				}
-			} else if col >= 0 && col < len(rec) {
-				v = rec[col]
-			}
-			values = append(values, v)
-		}
-		key := strings.Join(values, sep)
+		normalized := plan.normalizeRow(rec)
+		key := plan.dedupeKey(rec, normalized, conf.Dedupe.UseNormalized, sep)
 
 		skip := false
 		if strings.TrimSpace(key) == "" {
@@ -253,22 +227,99 @@ func Process(inFile, outFile string, conf config.Config, log logging.Logger) err
 			emptyKey++
 		}
 
-		if conf.Dedupe.ReplaceTarget && len(dedupeCols) > 0 && !skip {
-			firstCol := dedupeCols[0]
+		if conf.Dedupe.ReplaceTarget && len(plan.dedupeCols) > 0 && !skip {
+			firstCol := plan.dedupeCols[0]
 			if firstCol >= 0 && firstCol < len(rec) {
 				rec[firstCol] = key
 			}
 		}
+		rec = plan.expand(rec, normalized)
 		if conf.Dedupe.AppendKey {
 			rec = append(rec, key)
 		}
 
+		if spill != nil {
+			if err := spill.add(rec, key, skip); err != nil {
+				return err
+			}
+			continue
+		}
 		rows = append(rows, row{fields: rec, key: key, skipDedup: skip})
 	}
 
+	if spill != nil {
+		n, d, err := spill.finish(w.Write)
+		if err != nil {
+			return err
+		}
+		wrote, dropped = wrote+n, d
+		log.Debugf("spill: memory_limit=%s runs=%d merges=%d temp_bytes=%d merge_bytes=%d",
+			conf.Dedupe.MemoryLimit, spill.runCount, spill.merges, spill.spilled, spill.merged)
+		if err := spill.Close(); err != nil {
+			return err
+		}
+	}
+
+	keys := make([]string, len(rows)) // 空キーの行は ""
+	for i := range rows {
+		if !rows[i].skipDedup {
+			keys[i] = rows[i].key
+		}
+	}
+
+	// あいまい一致: クラスタ ID を列に追加し、以降は ID を重複判定のキーにする
+	if clustered {
+		var ids []int
+		var st clusterStats
+		if conf.Dedupe.Mode == "lsh" {
+			ids, st = clusterLSH(keys, conf.Dedupe)
+			if st.maxBlock > largeBlock {
+				log.Warnf("dedupe.lsh: %d 件のキーが同じバケットに入りました（lsh_rows を増やすと候補が絞られます）", st.maxBlock)
+			}
+			log.Debugf("lsh: shingle_size=%d bands=%d rows=%d threshold=%g keys=%d clusters=%d comparisons=%d max_bucket=%d",
+				conf.Dedupe.ShingleSize, conf.Dedupe.LSHBands, conf.Dedupe.LSHRows, conf.Dedupe.Threshold, st.keys, st.clusters, st.comparisons, st.maxBlock)
+		} else {
+			ids, st, err = clusterFuzzy(keys, conf.Dedupe, sep)
+			if err != nil {
+				return err
+			}
+			if st.maxBlock > largeBlock {
+				log.Warnf("dedupe.blocking: %d 行のブロックがあります（prefix/suffix の文字数を増やすと速くなります）", st.maxBlock)
+			}
+			log.Debugf("fuzzy: similarity=%s threshold=%g keys=%d clusters=%d comparisons=%d max_block=%d",
+				conf.Dedupe.Similarity, conf.Dedupe.Threshold, st.keys, st.clusters, st.comparisons, st.maxBlock)
+		}
+		for i := range rows {
+			id := ""
+			if ids[i] > 0 {
+				id = strconv.Itoa(ids[i])
+				rows[i].key = id
+			}
+			rows[i].fields = append(rows[i].fields, id)
+		}
+	}
+
+	if grouped {
+		// fuzzy/lsh ではクラスタがグループ。hash の ID はクラスタで最初に出てきたキーから作る
+		groups := make([]string, len(rows))
+		for i := range rows {
+			if !rows[i].skipDedup {
+				groups[i] = rows[i].key
+			}
+		}
+		for i, cols := range groupColumns(groups, keys, conf.Dedupe) {
+			rows[i].fields = append(rows[i].fields, cols...)
+		}
+	}
+
 	seen := map[string]struct{}{}
-	switch conf.Dedupe.Keep {
-	case "last":
+	switch {
+	case !conf.Dedupe.DropDuplicates:
+		for i := range rows {
+			_ = w.Write(rows[i].fields)
+			wrote++
+		}
+	case conf.Dedupe.Keep == "last":
 		keep := make([]bool, len(rows))
 		for i := len(rows) - 1; i >= 0; i-- {
 			if rows[i].skipDedup {
@@ -289,7 +340,7 @@ func Process(inFile, outFile string, conf config.Config, log logging.Logger) err
 				wrote++
 			}
 		}
-	default: // first
+	default: // keep=first
 		for i := 0; i < len(rows); i++ {
 			if rows[i].skipDedup {
 				_ = w.Write(rows[i].fields)
@@ -311,7 +362,59 @@ func Process(inFile, outFile string, conf config.Config, log logging.Logger) err
 	if err := w.Error(); err != nil {
 		return err
 	}
-	log.Debugf("dedupe: rows_read=%d wrote=%d dropped=%d empty_keys=%d keep=%s",
-		total, wrote, dropped, emptyKey, conf.Dedupe.Keep)
-	return nil
+	log.Debugf("dedupe: rows_read=%d wrote=%d dropped=%d empty_keys=%d type_errors=%d mode=%s keep=%s",
+		total, wrote, dropped, emptyKey, rep.count, conf.Dedupe.Mode, conf.Dedupe.Keep)
+	return rep.Close()
+}
+
+// outputHeader は出力用ヘッダ（正規化値の列・キー列を追加したもの）を返す
+func outputHeader(header []string, plan *rowPlan, conf config.Config) []string {
+	out := plan.expandHeader(header)
+	if conf.Dedupe.Enabled && conf.Dedupe.AppendKey {
+		out = append(out, conf.Dedupe.OutputHeader)
+	}
+	if conf.Dedupe.Enabled && (conf.Dedupe.Mode == "fuzzy" || conf.Dedupe.Mode == "lsh") {
+		out = append(out, conf.Dedupe.ClusterHeader)
+	}
+	if conf.Dedupe.Enabled && conf.Dedupe.AppendGroupID {
+		out = append(out, conf.Dedupe.GroupIDHeader)
+	}
+	if conf.Dedupe.Enabled && conf.Dedupe.AppendDupCount {
+		out = append(out, conf.Dedupe.DupCountHeader)
+	}
+	if conf.Dedupe.Enabled && conf.Dedupe.AppendDupRank {
+		out = append(out, conf.Dedupe.DupRankHeader)
+	}
+	return out
+}
+
+// recordReader は先読みした 1 行を返してから csv.Reader を読み進める
+type recordReader struct {
+	*csv.Reader
+	log     logging.Logger
+	pending []string
+}
+
+// peek は次の行を読み進めずに返す。本文の読取と同じく、読めない行はログに出して飛ばす
+func (r *recordReader) peek() ([]string, error) {
+	for r.pending == nil {
+		rec, err := r.Reader.Read()
+		if err == io.EOF {
+			return nil, err
+		}
+		if err != nil {
+			r.log.Errorf("CSV 読取エラー: %v", err)
+			continue
+		}
+		r.pending = rec
+	}
+	return r.pending, nil
+}
+
+func (r *recordReader) Read() ([]string, error) {
+	if rec := r.pending; rec != nil {
+		r.pending = nil
+		return rec, nil
+	}
+	return r.Reader.Read()
 }
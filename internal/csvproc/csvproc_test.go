@@ -0,0 +1,488 @@
+package csvproc
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/yourorg/strcleaner/internal/config"
+)
+
+// runProcess は in を CSV として Process に通し、出力を文字列で返す
+func runProcess(t *testing.T, in string, conf config.Config) string {
+	t.Helper()
+	dir := t.TempDir()
+	inPath := filepath.Join(dir, "in.csv")
+	outPath := filepath.Join(dir, "out.csv")
+	if err := os.WriteFile(inPath, []byte(in), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	conf.Output.UTF8BOM = false
+	conf.Output.LineEnding = "lf"
+	log := logrus.New()
+	log.SetOutput(io.Discard)
+	if err := Process(inPath, outPath, conf, log); err != nil {
+		t.Fatal(err)
+	}
+	b, err := os.ReadFile(outPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+func loadDefault(t *testing.T) config.Config {
+	t.Helper()
+	c, err := config.Load("", nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestProcess_ColumnRules(t *testing.T) {
+	conf := loadDefault(t)
+	conf.HasHeader = true
+	conf.Columns = nil
+	conf.Normalize = config.NormalizeConfig{WriteBack: true}
+	conf.Profiles = map[string]config.NormalizeConfig{
+		"title":  {ToLower: true, RemoveParens: true, WriteBack: true},
+		"author": {RemoveChars: config.MultiChars{Items: []string{" "}}, WriteBack: true},
+	}
+	conf.ColumnRules = []config.ColumnRule{
+		{Column: "題目", Profile: "title"},
+		{Column: "2", Profile: "author"},
+	}
+	conf.Dedupe.Enabled = true
+	conf.Dedupe.AppendKey = true
+
+	got := runProcess(t, "題目,著者\nＡ（Ｂ）,山田　太郎\n", conf)
+	want := "題目,著者,__dedupe_key\nab,山田太郎,ab|山田太郎\n"
+	if got != want {
+		t.Fatalf("want %q got %q", want, got)
+	}
+}
+
+func TestBuildPlan_Profiles(t *testing.T) {
+	conf := loadDefault(t)
+	conf.Columns = []string{"1"}
+	conf.Profiles = map[string]config.NormalizeConfig{
+		"title":  {ToLower: true},
+		"author": {StripDiacritics: true},
+	}
+	conf.ColumnRules = []config.ColumnRule{
+		{Column: "題目", Profile: "title"},
+		{Column: "3", Profile: "author"},
+	}
+	p, err := buildPlan(conf, []string{"ID", "題目", "著者", "備考"}, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(p.targets) != "[0 1 2]" {
+		t.Fatalf("targets: %v", p.targets)
+	}
+	if o := p.planFor(1).opts; !o.ToLower || o.StripDiacritics {
+		t.Fatalf("題目: %+v", o)
+	}
+	if o := p.planFor(2).opts; !o.StripDiacritics || o.ToLower {
+		t.Fatalf("著者: %+v", o)
+	}
+	if p.planFor(0) != p.base || p.planFor(3) != p.base {
+		t.Fatal("columns without a rule should use normalize")
+	}
+}
+
+func TestProcess_ProfileDoesNotInheritNormalize(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	yml := "has_header: true\ncolumns: [\"*\"]\nprofiles:\n  title:\n    to_lower: true\n    write_back: true\ncolumn_rules:\n  - { column: 題目, profile: title }\n"
+	if err := os.WriteFile(path, []byte(yml), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	conf, err := config.Load(path, nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 題目 は to_lower だけ（dash_to_hyphen はかからない）。番号 は normalize の既定値
+	got := runProcess(t, "題目,番号\nAB‐1,AB‐1\n", conf)
+	if want := "題目,番号\nab‐1,AB-1\n"; got != want {
+		t.Fatalf("want %q got %q", want, got)
+	}
+}
+
+func TestResolveColumn(t *testing.T) {
+	header := []string{"\ufeff題目", "著者"}
+	if col, err := resolveColumn("題目", header); err != nil || col != 0 {
+		t.Fatalf("want 0 got %d (%v)", col, err)
+	}
+	if col, err := resolveColumn("2", header); err != nil || col != 1 {
+		t.Fatalf("want 1 got %d (%v)", col, err)
+	}
+	if _, err := resolveColumn("備考", header); err == nil {
+		t.Fatal("want error for missing header name")
+	}
+}
+
+func TestResolveColumns(t *testing.T) {
+	header := []string{"ID", "題目", "著者", "発行年", "備考"}
+	cases := []struct {
+		items []string
+		want  []int
+	}{
+		{[]string{"題目", "著者"}, []int{1, 2}},
+		{[]string{"2-4"}, []int{1, 2, 3}},
+		{[]string{"*", "!備考", "!1"}, []int{1, 2, 3}},
+		{[]string{"!備考"}, []int{0, 1, 2, 3}},
+		{[]string{"著者", "2-3"}, []int{2, 1}},
+	}
+	for _, tc := range cases {
+		got, err := resolveColumns(tc.items, header, 0)
+		if err != nil {
+			t.Fatalf("%v: %v", tc.items, err)
+		}
+		if fmt.Sprint(got) != fmt.Sprint(tc.want) {
+			t.Fatalf("%v: want %v got %v", tc.items, tc.want, got)
+		}
+	}
+	if _, err := resolveColumns([]string{"*", "!所属"}, header, 0); err == nil {
+		t.Fatal("want error for missing header name")
+	}
+}
+
+func TestProcess_AllColumnsWithoutHeader(t *testing.T) {
+	conf := loadDefault(t)
+	conf.Columns = []string{"*", "!2"}
+	conf.Normalize = config.NormalizeConfig{ToLower: true, WriteBack: true}
+
+	got := runProcess(t, "Ａ,Ｂ,Ｃ\nＤ,Ｅ,Ｆ\n", conf)
+	want := "a,Ｂ,c\nd,Ｅ,f\n"
+	if got != want {
+		t.Fatalf("want %q got %q", want, got)
+	}
+}
+
+// failOnce は最初の Read だけ失敗する
+type failOnce struct {
+	r      io.Reader
+	failed bool
+}
+
+func (f *failOnce) Read(p []byte) (int, error) {
+	if !f.failed {
+		f.failed = true
+		return 0, errors.New("disk error")
+	}
+	return f.r.Read(p)
+}
+
+func TestRecordReader_PeekSkipsBadRow(t *testing.T) {
+	var buf strings.Builder
+	log := logrus.New()
+	log.SetOutput(&buf)
+	cr := csv.NewReader(&failOnce{r: strings.NewReader("a,b,c\nd,e,f\n")})
+	cr.FieldsPerRecord = -1
+	r := &recordReader{Reader: cr, log: log}
+
+	rec, err := r.peek()
+	if err != nil || strings.Join(rec, ",") != "a,b,c" {
+		t.Fatalf("peek: %v (%v)", rec, err)
+	}
+	if !strings.Contains(buf.String(), "disk error") {
+		t.Fatalf("read error not logged: %q", buf.String())
+	}
+	for _, want := range []string{"a,b,c", "d,e,f"} {
+		if rec, err := r.Read(); err != nil || strings.Join(rec, ",") != want {
+			t.Fatalf("want %s got %v (%v)", want, rec, err)
+		}
+	}
+	if _, err := r.peek(); err != io.EOF {
+		t.Fatalf("want EOF got %v", err)
+	}
+}
+
+func TestProcess_AppendNormalized(t *testing.T) {
+	conf := loadDefault(t)
+	conf.HasHeader = true
+	conf.Columns = []string{"題目", "著者"}
+	conf.Normalize = config.NormalizeConfig{ToLower: true, AppendNormalized: true}
+	conf.Dedupe.Enabled = true
+	conf.Dedupe.AppendKey = true
+	conf.Dedupe.Columns = []string{"題目"}
+
+	in := "題目,著者,年\nＡＢ,Ｃ,2024\n"
+
+	got := runProcess(t, in, conf)
+	want := "題目,著者,年,題目_normalized,著者_normalized,__dedupe_key\nＡＢ,Ｃ,2024,ab,c,ab\n"
+	if got != want {
+		t.Fatalf("end: want %q got %q", want, got)
+	}
+
+	conf.NormalizedColumns = config.NormalizedColumnsConfig{Suffix: "(正規化)", Position: "after"}
+	got = runProcess(t, in, conf)
+	want = "題目,題目(正規化),著者,著者(正規化),年,__dedupe_key\nＡＢ,ab,Ｃ,c,2024,ab\n"
+	if got != want {
+		t.Fatalf("after: want %q got %q", want, got)
+	}
+}
+
+func TestProcess_DateErrorReport(t *testing.T) {
+	conf := loadDefault(t)
+	conf.HasHeader = true
+	conf.Columns = nil
+	conf.Profiles = map[string]config.NormalizeConfig{
+		"date": {Type: "date", OnError: "report", WriteBack: true},
+	}
+	conf.ColumnRules = []config.ColumnRule{{Column: "契約日", Profile: "date"}}
+	conf.ErrorReport = filepath.Join(t.TempDir(), "errors.csv")
+
+	got := runProcess(t, "名前,契約日\nA,R6.4.1\nB,不明\nC,H31/4/30\n", conf)
+	want := "名前,契約日\nA,2024-04-01\nB,不明\nC,2019-04-30\n"
+	if got != want {
+		t.Fatalf("want %q got %q", want, got)
+	}
+	b, err := os.ReadFile(conf.ErrorReport)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantReport := "row,column,type,value,reason\n2,契約日,date,不明,unrecognized date format\n"
+	if string(b) != wantReport {
+		t.Fatalf("report: want %q got %q", wantReport, string(b))
+	}
+}
+
+func TestSimilarity(t *testing.T) {
+	cases := []struct {
+		name string
+		fn   similarityFunc
+		a, b string
+		want float64
+	}{
+		{"levenshtein", levenshteinSimilarity, "kitten", "sitting", 1 - 3.0/7},
+		{"levenshtein/same", levenshteinSimilarity, "東京都", "東京都", 1},
+		{"jaro_winkler", jaroWinkler, "MARTHA", "MARHTA", 0.9611},
+		{"jaro_winkler/odd", jaroWinkler, "abcdef", "bcadef", (2 + 4.5/6) / 3}, // 不一致 3 文字（半分は 1.5）
+		{"jaro_winkler/none", jaroWinkler, "abc", "xyz", 0},
+		{"ngram", func(a, b []rune) float64 { return ngramJaccard(a, b, 2) }, "night", "nacht", 1.0 / 7},
+	}
+	for _, c := range cases {
+		got := c.fn([]rune(c.a), []rune(c.b))
+		if got < c.want-0.0001 || got > c.want+0.0001 {
+			t.Fatalf("%s(%q, %q): want %.4f got %.4f", c.name, c.a, c.b, c.want, got)
+		}
+	}
+}
+
+func TestProcess_Fuzzy(t *testing.T) {
+	conf := loadDefault(t)
+	conf.HasHeader = true
+	conf.Columns = []string{"題目"}
+	conf.Normalize = config.NormalizeConfig{ToLower: true, WriteBack: false}
+	conf.Dedupe.Enabled = true
+	conf.Dedupe.Columns = []string{"題目"}
+	conf.Dedupe.Mode = "fuzzy"
+	conf.Dedupe.Threshold = 0.9
+
+	in := "題目,年\nIntroduction to Algorithms,1990\nData Structures,1983\nIntroduction to Algorithm,2001\n,2005\nIntroductoin to Algorithms,2009\n"
+	got := runProcess(t, in, conf)
+	want := "題目,年,__cluster_id\nIntroduction to Algorithms,1990,1\nData Structures,1983,2\nIntroduction to Algorithm,2001,1\n,2005,\nIntroductoin to Algorithms,2009,1\n"
+	if got != want {
+		t.Fatalf("cluster: want %q got %q", want, got)
+	}
+
+	// drop_duplicates ではクラスタごとに 1 行残す（空キーの行は残す）
+	conf.Dedupe.DropDuplicates = true
+	conf.Dedupe.Keep = "last"
+	got = runProcess(t, in, conf)
+	want = "題目,年,__cluster_id\nData Structures,1983,2\n,2005,\nIntroductoin to Algorithms,2009,1\n"
+	if got != want {
+		t.Fatalf("drop: want %q got %q", want, got)
+	}
+}
+
+func TestProcess_LSH(t *testing.T) {
+	conf := loadDefault(t)
+	conf.HasHeader = true
+	conf.Columns = []string{"題目"}
+	conf.Normalize = config.NormalizeConfig{ToLower: true}
+	conf.Dedupe.Enabled = true
+	conf.Dedupe.Columns = []string{"題目"}
+	conf.Dedupe.AppendKey = true
+	conf.Dedupe.Mode = "lsh"
+
+	in := "題目\n" +
+		"Introduction to Algorithms Third Edition\n" +
+		"The Art of Computer Programming\n" +
+		"Introduction to Algorithms Third Editon\n" +
+		"INTRODUCTION TO ALGORITHMS THIRD EDITION\n"
+	got := runProcess(t, in, conf)
+	want := "題目,__dedupe_key,__cluster_id\n" +
+		"Introduction to Algorithms Third Edition,introduction to algorithms third edition,1\n" +
+		"The Art of Computer Programming,the art of computer programming,2\n" +
+		"Introduction to Algorithms Third Editon,introduction to algorithms third editon,1\n" +
+		"INTRODUCTION TO ALGORITHMS THIRD EDITION,introduction to algorithms third edition,1\n"
+	if got != want {
+		t.Fatalf("want %q got %q", want, got)
+	}
+}
+
+// 帯の一致は候補にすぎない。似ているが別のキーが連鎖して 1 つのクラスタにならないこと
+func TestClusterLSH_Distinct(t *testing.T) {
+	conf := loadDefault(t)
+	words := []string{"physics", "chemistry", "biology", "history", "economics", "law", "medicine",
+		"music", "geology", "linguistics", "philosophy", "astronomy", "ecology", "sociology", "robotics"}
+	var keys []string
+	for i, x := range words {
+		for _, y := range words[i+1:] {
+			keys = append(keys, "journal of "+x+" and "+y+" studies")
+		}
+	}
+	keys = append(keys, "journal of physics and chemistry studie") // 脱字はまとまる
+	ids, st := clusterLSH(keys, conf.Dedupe)
+	if st.clusters != len(keys)-1 {
+		t.Fatalf("want %d clusters got %d", len(keys)-1, st.clusters)
+	}
+	if ids[0] != ids[len(keys)-1] {
+		t.Fatalf("typo not merged: %d vs %d", ids[0], ids[len(keys)-1])
+	}
+}
+
+func TestProcess_SpillDedupe(t *testing.T) {
+	var b strings.Builder
+	b.WriteString("id,key\n")
+	for i := 0; i < 300; i++ {
+		fmt.Fprintf(&b, "%d,k%02d\n", i, i*7%97)
+		if i%50 == 0 {
+			fmt.Fprintf(&b, "%d,\n", i) // 空キーは落とさない
+		}
+	}
+	in := b.String()
+
+	for _, keep := range []string{"first", "last"} {
+		conf := loadDefault(t)
+		conf.HasHeader = true
+		conf.Columns = []string{"key"}
+		conf.Dedupe.Enabled = true
+		conf.Dedupe.Columns = []string{"key"}
+		conf.Dedupe.DropDuplicates = true
+		conf.Dedupe.Keep = keep
+		want := runProcess(t, in, conf)
+
+		// 1 キーごとに run を書き出し、run の統合も通す
+		conf.Dedupe.MemoryLimit = "1B"
+		conf.Dedupe.SpillDir = t.TempDir()
+		got := runProcess(t, in, conf)
+		if got != want {
+			t.Fatalf("keep=%s: spill output differs\nwant %q\ngot  %q", keep, want, got)
+		}
+		if entries, _ := os.ReadDir(conf.Dedupe.SpillDir); len(entries) != 0 {
+			t.Fatalf("keep=%s: temp files left: %v", keep, entries)
+		}
+	}
+}
+
+// run は段ごとにまとめ、まとめた run を毎回書き直さない
+func TestSpillDedupe_Levels(t *testing.T) {
+	s, err := newSpillDedupe(t.TempDir(), 1, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	n := maxRuns*maxRuns + 5
+	for i := 0; i < n; i++ {
+		k := fmt.Sprintf("k%d", i%1000)
+		if err := s.add([]string{k}, k, false); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// 段 0 の 64 個を 64 回、段 1 の 64 個を 1 回まとめ、段 0 に 5 個・段 2 に 1 個が残る
+	if s.merges != maxRuns+1 || len(s.runs()) != 6 {
+		t.Fatalf("merges=%d runs=%d", s.merges, len(s.runs()))
+	}
+	wrote, dropped, err := s.finish(func([]string) error { return nil })
+	if err != nil || wrote != 1000 || dropped != n-1000 {
+		t.Fatalf("wrote=%d dropped=%d err=%v", wrote, dropped, err)
+	}
+}
+
+func TestKeyFilter(t *testing.T) {
+	for _, verify := range []bool{false, true} {
+		f := newKeyFilter(verify)
+		if f.seen("a|b") || f.seen("a|c") || !f.seen("a|b") {
+			t.Fatalf("verify=%t: unexpected seen result", verify)
+		}
+		if f.len() != 2 || f.memBytes() <= 0 {
+			t.Fatalf("verify=%t: len=%d mem=%d", verify, f.len(), f.memBytes())
+		}
+	}
+
+	// ハッシュが衝突しても verify ならキーを取り違えない
+	f := newKeyFilter(true)
+	f.keys[f.hash("x")] = []string{"別のキー"}
+	if f.seen("x") {
+		t.Fatal("collision treated as duplicate")
+	}
+	if !f.seen("x") {
+		t.Fatal("second x not detected")
+	}
+}
+
+func TestProcess_StreamKeepFirst(t *testing.T) {
+	conf := loadDefault(t)
+	conf.HasHeader = true
+	conf.Columns = []string{"題目"}
+	conf.Normalize = config.NormalizeConfig{ToLower: true, WriteBack: false}
+	conf.Dedupe.Enabled = true
+	conf.Dedupe.Columns = []string{"題目"}
+	conf.Dedupe.DropDuplicates = true
+
+	in := "題目,年\nGo,2009\nRust,2010\nGO,2012\n,2013\n,2014\nrust,2015\n"
+	want := "題目,年\nGo,2009\nRust,2010\n,2013\n,2014\n"
+	for _, verify := range []bool{false, true} {
+		conf.Dedupe.VerifyKeys = verify
+		if got := runProcess(t, in, conf); got != want {
+			t.Fatalf("verify=%t: want %q got %q", verify, want, got)
+		}
+	}
+}
+
+func TestProcess_GroupColumns(t *testing.T) {
+	conf := loadDefault(t)
+	conf.HasHeader = true
+	conf.Columns = []string{"題目"}
+	conf.Normalize = config.NormalizeConfig{ToLower: true, WriteBack: false}
+	conf.Dedupe.Enabled = true
+	conf.Dedupe.Columns = []string{"題目"}
+	conf.Dedupe.AppendGroupID = true
+	conf.Dedupe.AppendDupCount = true
+	conf.Dedupe.AppendDupRank = true
+
+	in := "題目,年\nGo,2009\nRust,2010\nGO,2012\n,2013\ngo,2015\n"
+	want := "題目,年,__group_id,__dup_count,__dup_rank\n" +
+		"Go,2009,1,3,1\nRust,2010,2,1,1\nGO,2012,1,3,2\n,2013,,,\ngo,2015,1,3,3\n"
+	if got := runProcess(t, in, conf); got != want {
+		t.Fatalf("want %q got %q", want, got)
+	}
+
+	// keep=last は最後の行が順位 1。drop_duplicates なら順位 1 の行だけが残る
+	conf.Dedupe.Keep = "last"
+	conf.Dedupe.DropDuplicates = true
+	want = "題目,年,__group_id,__dup_count,__dup_rank\n" +
+		"Rust,2010,2,1,1\n,2013,,,\ngo,2015,1,3,1\n"
+	if got := runProcess(t, in, conf); got != want {
+		t.Fatalf("keep=last: want %q got %q", want, got)
+	}
+
+	// hash はキーだけで決まる
+	conf.Dedupe.GroupIDFormat = "hash"
+	conf.Dedupe.AppendDupCount = false
+	conf.Dedupe.AppendDupRank = false
+	got := runProcess(t, in, conf)
+	if !strings.Contains(got, "go,2015,"+groupHash("go")+"\n") {
+		t.Fatalf("hash id: got %q", got)
+	}
+}
@@ -0,0 +1,132 @@
+package csvproc
+
+import (
+	"fmt"
+	"hash/fnv"
+	"hash/maphash"
+	"strconv"
+
+	"github.com/yourorg/strcleaner/internal/config"
+)
+
+// keyHash はキーの 128bit ハッシュ（シードの異なる 2 つの 64bit ハッシュ）
+type keyHash [2]uint64
+
+// keyHashEntryBytes は map[keyHash]struct{} の 1 件あたりのメモリの目安（バケットの空きを含む）
+const keyHashEntryBytes = 24
+
+// keyFilter は keep=first をストリーミングで行うための既出キーの集合。
+// 既定ではキーの文字列を持たず、固定長のハッシュだけを覚える。
+// verify なら衝突に備えてキーの文字列も保持し、ハッシュが一致したときに照合する。
+type keyFilter struct {
+	seeds    [2]maphash.Seed
+	hashes   map[keyHash]struct{}
+	keys     map[keyHash][]string // verify 時のみ
+	n        int                  // 記録したキーの数
+	keyBytes int64
+}
+
+func newKeyFilter(verify bool) *keyFilter {
+	f := &keyFilter{seeds: [2]maphash.Seed{maphash.MakeSeed(), maphash.MakeSeed()}}
+	if verify {
+		f.keys = map[keyHash][]string{}
+	} else {
+		f.hashes = map[keyHash]struct{}{}
+	}
+	return f
+}
+
+func (f *keyFilter) hash(key string) keyHash {
+	return keyHash{maphash.String(f.seeds[0], key), maphash.String(f.seeds[1], key)}
+}
+
+// seen はキーが既出なら true を返す。初出なら記録して false。
+func (f *keyFilter) seen(key string) bool {
+	h := f.hash(key)
+	if f.keys == nil {
+		if _, ok := f.hashes[h]; ok {
+			return true
+		}
+		f.hashes[h] = struct{}{}
+		f.n++
+		return false
+	}
+	for _, k := range f.keys[h] {
+		if k == key {
+			return true
+		}
+	}
+	f.keys[h] = append(f.keys[h], key)
+	f.n++
+	f.keyBytes += int64(len(key)) + 16 + 24 // 文字列本体＋ヘッダ＋スライス
+	return false
+}
+
+// len は記録したキーの数
+func (f *keyFilter) len() int { return f.n }
+
+// memBytes は集合が使うメモリの目安（バイト）
+func (f *keyFilter) memBytes() int64 {
+	return int64(f.n)*keyHashEntryBytes + f.keyBytes
+}
+
+// groupColumns は append_group_id / append_dup_count / append_dup_rank の列の値を行ごとに返す。
+// groups は重複判定のキー（空なら対象外で列も空）、names は group_id_format: hash で ID の元にするキー。
+// 順位は keep で残す行を 1 とし、keep=first なら出現順、keep=last なら後ろから数える。
+func groupColumns(groups, names []string, d config.DedupeConfig) [][]string {
+	ids := map[string]string{}
+	count := map[string]int{}
+	for i, g := range groups {
+		if g == "" {
+			continue
+		}
+		if _, ok := ids[g]; !ok {
+			if d.GroupIDFormat == "hash" {
+				ids[g] = groupHash(names[i])
+			} else {
+				ids[g] = strconv.Itoa(len(ids) + 1)
+			}
+		}
+		count[g]++
+	}
+	rank := make([]int, len(groups))
+	seen := map[string]int{}
+	for j := range groups {
+		i := j
+		if d.Keep == "last" {
+			i = len(groups) - 1 - j
+		}
+		if g := groups[i]; g != "" {
+			seen[g]++
+			rank[i] = seen[g]
+		}
+	}
+
+	out := make([][]string, len(groups))
+	for i, g := range groups {
+		var cols []string
+		add := func(on bool, v string) {
+			if on {
+				cols = append(cols, v)
+			}
+		}
+		if g == "" {
+			add(d.AppendGroupID, "")
+			add(d.AppendDupCount, "")
+			add(d.AppendDupRank, "")
+		} else {
+			add(d.AppendGroupID, ids[g])
+			add(d.AppendDupCount, strconv.Itoa(count[g]))
+			add(d.AppendDupRank, strconv.Itoa(rank[i]))
+		}
+		out[i] = cols
+	}
+	return out
+}
+
+// groupHash はキーから決まるグループ ID（FNV-1a 64bit の 16 進 16 桁）。ファイルや実行をまたいで同じ値になる。
+func groupHash(key string) string {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(key))
+	return fmt.Sprintf("%016x", h.Sum64())
+}
@@ -0,0 +1,286 @@
+package csvproc
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/yourorg/strcleaner/internal/config"
+)
+
+// largeBlock を超える行数のブロックは比較回数が膨らむため警告する
+const largeBlock = 10000
+
+// similarityFunc は 2 つのキーの類似度（0〜1、1 が一致）を返す
+type similarityFunc func(a, b []rune) float64
+
+func similarityFor(name string, n int) similarityFunc {
+	switch name {
+	case "jaro_winkler":
+		return jaroWinkler
+	case "ngram":
+		return func(a, b []rune) float64 { return ngramJaccard(a, b, n) }
+	}
+	return levenshteinSimilarity
+}
+
+// levenshteinSimilarity は 1 - 編集距離/長い方の文字数
+func levenshteinSimilarity(a, b []rune) float64 {
+	m := max(len(a), len(b))
+	if m == 0 {
+		return 1
+	}
+	return 1 - float64(levenshtein(a, b))/float64(m)
+}
+
+func levenshtein(a, b []rune) int {
+	if len(a) < len(b) {
+		a, b = b, a
+	}
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+// jaroWinkler は Jaro 類似度に共通接頭辞（最大 4 文字）の重みを加えたもの
+func jaroWinkler(a, b []rune) float64 {
+	if len(a) == 0 && len(b) == 0 {
+		return 1
+	}
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	window := max(len(a), len(b))/2 - 1
+	window = max(window, 0)
+	ma := make([]bool, len(a))
+	mb := make([]bool, len(b))
+	matches := 0
+	for i := range a {
+		lo, hi := max(0, i-window), min(len(b), i+window+1)
+		for j := lo; j < hi; j++ {
+			if !mb[j] && a[i] == b[j] {
+				ma[i], mb[j] = true, true
+				matches++
+				break
+			}
+		}
+	}
+	if matches == 0 {
+		return 0
+	}
+	transpositions := 0
+	for i, j := 0, 0; i < len(a); i++ {
+		if !ma[i] {
+			continue
+		}
+		for !mb[j] {
+			j++
+		}
+		if a[i] != b[j] {
+			transpositions++
+		}
+		j++
+	}
+	m := float64(matches)
+	jaro := (m/float64(len(a)) + m/float64(len(b)) + (m-float64(transpositions)/2)/m) / 3
+	prefix := 0
+	for prefix < min(4, len(a), len(b)) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	return jaro + float64(prefix)*0.1*(1-jaro)
+}
+
+// ngramJaccard は文字 n-gram の集合の Jaccard 係数（n 文字未満のキーはキー全体を 1 つの n-gram とする）
+func ngramJaccard(a, b []rune, n int) float64 {
+	ga, gb := ngrams(a, n), ngrams(b, n)
+	if len(ga) == 0 && len(gb) == 0 {
+		return 1
+	}
+	inter := 0
+	for g := range ga {
+		if _, ok := gb[g]; ok {
+			inter++
+		}
+	}
+	return float64(inter) / float64(len(ga)+len(gb)-inter)
+}
+
+func ngrams(s []rune, n int) map[string]struct{} {
+	out := map[string]struct{}{}
+	if len(s) == 0 {
+		return out
+	}
+	if len(s) <= n {
+		out[string(s)] = struct{}{}
+		return out
+	}
+	for i := 0; i+n <= len(s); i++ {
+		out[string(s[i:i+n])] = struct{}{}
+	}
+	return out
+}
+
+// blockValues はキーが属するブロックの値を返す
+func blockValues(bk config.BlockingKey, key []rune, sep string) []string {
+	switch bk.Kind {
+	case "prefix":
+		return []string{string(key[:min(bk.N, len(key))])}
+	case "suffix":
+		return []string{string(key[max(0, len(key)-bk.N):])}
+	}
+	// token
+	tokens := strings.FieldsFunc(string(key), func(r rune) bool {
+		return unicode.IsSpace(r) || strings.ContainsRune(sep, r)
+	})
+	seen := make(map[string]struct{}, len(tokens))
+	out := tokens[:0]
+	for _, t := range tokens {
+		if _, dup := seen[t]; !dup {
+			seen[t] = struct{}{}
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+// unionFind は素集合（経路圧縮つき）
+type unionFind []int
+
+func newUnionFind(n int) unionFind {
+	uf := make(unionFind, n)
+	for i := range uf {
+		uf[i] = i
+	}
+	return uf
+}
+
+func (uf unionFind) find(i int) int {
+	for uf[i] != i {
+		uf[i] = uf[uf[i]]
+		i = uf[i]
+	}
+	return i
+}
+
+func (uf unionFind) union(i, j int) {
+	if ri, rj := uf.find(i), uf.find(j); ri != rj {
+		uf[max(ri, rj)] = min(ri, rj)
+	}
+}
+
+// clusterStats はあいまい一致の統計（-v のデバッグ出力用）
+type clusterStats struct {
+	keys        int // 異なるキーの数
+	clusters    int
+	comparisons int
+	maxBlock    int
+}
+
+// clusterIDs は各行のクラスタ ID（出現順に 1 から。空キーの行は 0）を返す
+func clusterIDs(keys []string, uf unionFind, index []int) ([]int, int) {
+	ids := make([]int, len(keys))
+	byRoot := map[int]int{}
+	for i, u := range index {
+		if u < 0 {
+			continue
+		}
+		root := uf.find(u)
+		id, ok := byRoot[root]
+		if !ok {
+			id = len(byRoot) + 1
+			byRoot[root] = id
+		}
+		ids[i] = id
+	}
+	return ids, len(byRoot)
+}
+
+// uniqueKeys は空でないキーを重複なしに並べ、各行のキーの位置（空キーは -1）を返す
+func uniqueKeys(keys []string) (uniq []string, index []int) {
+	index = make([]int, len(keys))
+	pos := map[string]int{}
+	for i, k := range keys {
+		if strings.TrimSpace(k) == "" {
+			index[i] = -1
+			continue
+		}
+		u, ok := pos[k]
+		if !ok {
+			u = len(uniq)
+			pos[k] = u
+			uniq = append(uniq, k)
+		}
+		index[i] = u
+	}
+	return uniq, index
+}
+
+// clusterFuzzy はキーの類似度が threshold 以上の行を同じクラスタにまとめる（単連結）。
+// 比較するのはいずれかのブロッキングキーが一致する行どうしだけ。
+func clusterFuzzy(keys []string, d config.DedupeConfig, sep string) ([]int, clusterStats, error) {
+	blocking, err := config.ParseBlockingKeys(d.Blocking)
+	if err != nil {
+		return nil, clusterStats{}, err
+	}
+	sim := similarityFor(d.Similarity, d.NGram)
+	uniq, index := uniqueKeys(keys)
+	runes := make([][]rune, len(uniq))
+	for i, k := range uniq {
+		runes[i] = []rune(k)
+	}
+	st := clusterStats{keys: len(uniq)}
+	uf := newUnionFind(len(uniq))
+
+	for _, bk := range blocking {
+		blocks := map[string][]int{}
+		var order []string
+		for u, r := range runes {
+			for _, v := range blockValues(bk, r, sep) {
+				if _, ok := blocks[v]; !ok {
+					order = append(order, v)
+				}
+				blocks[v] = append(blocks[v], u)
+			}
+		}
+		for _, v := range order {
+			members := blocks[v]
+			st.maxBlock = max(st.maxBlock, len(members))
+			for i := 0; i < len(members); i++ {
+				for j := i + 1; j < len(members); j++ {
+					a, b := members[i], members[j]
+					if uf.find(a) == uf.find(b) {
+						continue
+					}
+					// 編集距離は文字数の差より小さくならない
+					if d.Similarity == "levenshtein" {
+						la, lb := len(runes[a]), len(runes[b])
+						if 1-float64(max(la, lb)-min(la, lb))/float64(max(la, lb)) < d.Threshold {
+							continue
+						}
+					}
+					st.comparisons++
+					if sim(runes[a], runes[b]) >= d.Threshold {
+						uf.union(a, b)
+					}
+				}
+			}
+		}
+	}
+
+	ids, n := clusterIDs(keys, uf, index)
+	st.clusters = n
+	return ids, st, nil
+}
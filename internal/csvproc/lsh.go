@@ -0,0 +1,128 @@
+package csvproc
+
+import (
+	"hash/fnv"
+	"slices"
+
+	"github.com/yourorg/strcleaner/internal/config"
+)
+
+// mix64 は splitmix64 の最終段（64bit 値をよく混ぜる）
+func mix64(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
+
+// minHasher はキーの文字 shingle 集合の MinHash 署名を作る
+type minHasher struct {
+	shingle int
+	seeds   []uint64
+}
+
+func newMinHasher(shingle, n int) *minHasher {
+	seeds := make([]uint64, n)
+	for i := range seeds {
+		seeds[i] = mix64(uint64(i+1) * 0x9e3779b97f4a7c15)
+	}
+	return &minHasher{shingle: shingle, seeds: seeds}
+}
+
+// shingles はキーの文字 shingle のハッシュを重複なしに昇順で返す（shingle 以下の長さのキーはキー全体を 1 つとする）
+func (m *minHasher) shingles(key []rune) []uint64 {
+	h := fnv.New64a()
+	hash := func(s []rune) uint64 {
+		h.Reset()
+		_, _ = h.Write([]byte(string(s)))
+		return h.Sum64()
+	}
+	var out []uint64
+	if len(key) <= m.shingle {
+		out = append(out, hash(key))
+	} else {
+		for i := 0; i+m.shingle <= len(key); i++ {
+			out = append(out, hash(key[i:i+m.shingle]))
+		}
+	}
+	slices.Sort(out)
+	return slices.Compact(out)
+}
+
+// signature は sig（長さ = ハッシュ関数の数）に shingle 集合の署名を書き込む
+func (m *minHasher) signature(shingles []uint64, sig []uint64) {
+	for i := range sig {
+		sig[i] = ^uint64(0)
+	}
+	for _, x := range shingles {
+		for i, seed := range m.seeds {
+			if v := mix64(x ^ seed); v < sig[i] {
+				sig[i] = v
+			}
+		}
+	}
+}
+
+// jaccardSorted は昇順・重複なしの 2 つの集合の Jaccard 係数
+func jaccardSorted(a, b []uint64) float64 {
+	inter := 0
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		switch {
+		case a[i] == b[j]:
+			inter++
+			i++
+			j++
+		case a[i] < b[j]:
+			i++
+		default:
+			j++
+		}
+	}
+	return float64(inter) / float64(len(a)+len(b)-inter)
+}
+
+// clusterLSH は MinHash 署名を lsh_bands 個の帯に分け、帯が一致したキーどうしを候補とする。
+// 候補は shingle 集合の Jaccard 係数が threshold 以上のときだけ同じクラスタにまとめる
+// （帯の一致だけでまとめると、似ていないキーが連鎖して 1 つのクラスタになってしまう）。
+// 比較するのは帯が一致した組だけなので、似たキーが少なければ行数にほぼ比例する時間で終わる。
+// 帯 b・帯あたり r 行のとき、Jaccard 係数がおよそ (1/b)^(1/r) を超える組が候補になりやすい。
+func clusterLSH(keys []string, d config.DedupeConfig) ([]int, clusterStats) {
+	uniq, index := uniqueKeys(keys)
+	st := clusterStats{keys: len(uniq)}
+	uf := newUnionFind(len(uniq))
+
+	mh := newMinHasher(d.ShingleSize, d.LSHBands*d.LSHRows)
+	sig := make([]uint64, d.LSHBands*d.LSHRows)
+	sets := make([][]uint64, len(uniq))
+	buckets := make([]map[uint64][]int, d.LSHBands)
+	for b := range buckets {
+		buckets[b] = map[uint64][]int{}
+	}
+	for u, k := range uniq {
+		sets[u] = mh.shingles([]rune(k))
+		mh.signature(sets[u], sig)
+		for b := 0; b < d.LSHBands; b++ {
+			bh := uint64(b)
+			for _, v := range sig[b*d.LSHRows : (b+1)*d.LSHRows] {
+				bh = mix64(bh ^ v)
+			}
+			for _, v := range buckets[b][bh] {
+				if uf.find(v) == uf.find(u) {
+					continue
+				}
+				st.comparisons++ // 候補ペアの確認回数
+				if jaccardSorted(sets[u], sets[v]) >= d.Threshold {
+					uf.union(v, u)
+				}
+			}
+			buckets[b][bh] = append(buckets[b][bh], u)
+			st.maxBlock = max(st.maxBlock, len(buckets[b][bh]))
+		}
+	}
+
+	ids, n := clusterIDs(keys, uf, index)
+	st.clusters = n
+	return ids, st
+}
@@ -0,0 +1,62 @@
+package csvproc
+
+import (
+	"encoding/csv"
+	"os"
+	"strconv"
+
+	"github.com/yourorg/strcleaner/internal/logging"
+	"github.com/yourorg/strcleaner/internal/normalize"
+)
+
+// errorReport は on_error: report の列で型として解釈できなかった値を記録する。
+// path が空ならログに警告を出すだけ。
+type errorReport struct {
+	f     *os.File
+	w     *csv.Writer
+	log   logging.Logger
+	count int
+}
+
+func openErrorReport(path string, log logging.Logger) (*errorReport, error) {
+	r := &errorReport{log: log}
+	if path == "" {
+		return r, nil
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	r.f = f
+	r.w = csv.NewWriter(f)
+	if err := r.w.Write([]string{"row", "column", "type", "value", "reason"}); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return r, nil
+}
+
+// add は 1 件記録する（row はデータ行の 1 オリジン番号、column は列名 or 列番号）
+func (r *errorReport) add(row int, column string, e *normalize.ValueError) {
+	r.count++
+	if r.w == nil {
+		r.log.Warnf("%d 行目 %s: %v", row, column, e)
+		return
+	}
+	_ = r.w.Write([]string{strconv.Itoa(row), column, e.Type, e.Value, e.Reason})
+}
+
+// Close はファイルを閉じる（2 回目以降は何もしない）
+func (r *errorReport) Close() error {
+	if r.f == nil {
+		return nil
+	}
+	f := r.f
+	r.f = nil
+	r.w.Flush()
+	if err := r.w.Error(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,464 @@
+package csvproc
+
+import (
+	"bufio"
+	"container/heap"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// keySeq はキーと行番号（0 オリジン）の組
+type keySeq struct {
+	key string
+	seq uint64
+}
+
+// keySeqOverhead は keySeq 1 件あたりの文字列以外のメモリの目安（バイト）
+const keySeqOverhead = 48
+
+// maxRuns は 1 回のマージでまとめる run の数（同時に開くファイルの数の上限）。
+// run は段（level）ごとに管理し、ある段に maxRuns 個たまったらそれだけを 1 つにまとめて次の段へ送る。
+// 各行のキーが書き直されるのは段の数（log_maxRuns(run の数)）回までなので、I/O は入力にほぼ比例する。
+const maxRuns = 64
+
+// spillDedupe は drop_duplicates の重複判定を一時ファイルで行う（dedupe.memory_limit 指定時）。
+//
+//  1. 行は読んだ順に rows ファイルへ書き出し、キーと行番号は limit までメモリに溜めて
+//     キー順に並べた run ファイルとして退避する
+//  2. run を k-way マージしてキーごとに残す行（最初 / 最後）を決め、行番号のビット表に印を付ける
+//  3. rows ファイルを先頭から読み直し、印の付いた行だけを出力する
+//
+// メモリに残るのは limit までのキーと、行数ぶんのビット表（100 万行で約 122KiB）だけ。
+type spillDedupe struct {
+	dir      string
+	limit    int64
+	keepLast bool
+
+	rows  *os.File
+	rowsW *bufio.Writer
+	n     uint64   // 行数
+	keep  []uint64 // 出力する行のビット表
+
+	chunk      []keySeq
+	chunkBytes int64
+	levels     [][]string // levels[0] がメモリから書き出した run、levels[l+1] は levels[l] をまとめた run
+	runCount   int        // 書き出した run の数（まとめた分を含む）
+	merges     int        // run をまとめた回数
+
+	spilled int64 // 一時ファイルに書いたバイト数（まとめ直した分を含む）
+	merged  int64 // うち、run をまとめ直して書いたバイト数
+}
+
+func newSpillDedupe(dir string, limit int64, keepLast bool) (*spillDedupe, error) {
+	tmp, err := os.MkdirTemp(dir, "strcleaner-dedupe-")
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Create(filepath.Join(tmp, "rows.bin"))
+	if err != nil {
+		os.RemoveAll(tmp)
+		return nil, err
+	}
+	return &spillDedupe{
+		dir:      tmp,
+		limit:    limit,
+		keepLast: keepLast,
+		rows:     f,
+		rowsW:    bufio.NewWriterSize(f, 1<<16),
+	}, nil
+}
+
+// add は 1 行を退避する。skip（空キー）の行は重複判定せず必ず出力する。
+func (s *spillDedupe) add(fields []string, key string, skip bool) error {
+	if err := writeRecord(s.rowsW, fields); err != nil {
+		return err
+	}
+	seq := s.n
+	s.n++
+	if seq%64 == 0 {
+		s.keep = append(s.keep, 0)
+	}
+	if skip {
+		s.mark(seq)
+		return nil
+	}
+	s.chunk = append(s.chunk, keySeq{key: key, seq: seq})
+	s.chunkBytes += int64(len(key)) + keySeqOverhead
+	if s.chunkBytes >= s.limit {
+		return s.flushRun()
+	}
+	return nil
+}
+
+func (s *spillDedupe) mark(seq uint64)        { s.keep[seq/64] |= 1 << (seq % 64) }
+func (s *spillDedupe) marked(seq uint64) bool { return s.keep[seq/64]&(1<<(seq%64)) != 0 }
+
+func sortChunk(c []keySeq) {
+	sort.Slice(c, func(i, j int) bool {
+		if c[i].key != c[j].key {
+			return c[i].key < c[j].key
+		}
+		return c[i].seq < c[j].seq
+	})
+}
+
+// flushRun はメモリ上のキーを並べて run ファイルに書き出す
+func (s *spillDedupe) flushRun() error {
+	if len(s.chunk) == 0 {
+		return nil
+	}
+	sortChunk(s.chunk)
+	path, _, err := s.writeRun(&sliceIter{items: s.chunk})
+	if err != nil {
+		return err
+	}
+	s.chunk = s.chunk[:0]
+	s.chunkBytes = 0
+	return s.addRun(0, path)
+}
+
+// addRun は run を段 level に加え、段が maxRuns 個で埋まったらまとめて次の段へ送る
+func (s *spillDedupe) addRun(level int, path string) error {
+	for len(s.levels) <= level {
+		s.levels = append(s.levels, nil)
+	}
+	s.levels[level] = append(s.levels[level], path)
+	if len(s.levels[level]) < maxRuns {
+		return nil
+	}
+	merged, err := s.mergeRuns(s.levels[level])
+	if err != nil {
+		return err
+	}
+	s.levels[level] = nil
+	return s.addRun(level+1, merged)
+}
+
+// mergeRuns は paths の run をマージして 1 つの run にし、元の run を削除する
+func (s *spillDedupe) mergeRuns(paths []string) (string, error) {
+	it, closeAll, err := openRuns(paths)
+	if err != nil {
+		return "", err
+	}
+	path, n, err := s.writeRun(it)
+	if cerr := closeAll(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return "", err
+	}
+	for _, old := range paths {
+		os.Remove(old)
+	}
+	s.merges++
+	s.merged += n
+	return path, nil
+}
+
+// runs は未マージの run をすべて返す（段の数 × maxRuns 個未満）
+func (s *spillDedupe) runs() []string {
+	var out []string
+	for _, l := range s.levels {
+		out = append(out, l...)
+	}
+	return out
+}
+
+// writeRun はキー順の it を新しい run ファイルに書き出し、パスと書いたバイト数を返す
+func (s *spillDedupe) writeRun(it keySeqIter) (string, int64, error) {
+	s.runCount++
+	path := filepath.Join(s.dir, fmt.Sprintf("run-%06d.bin", s.runCount))
+	f, err := os.Create(path)
+	if err != nil {
+		return "", 0, err
+	}
+	defer f.Close()
+	bw := bufio.NewWriterSize(f, 1<<16)
+	var buf [binary.MaxVarintLen64]byte
+	for {
+		ks, ok, err := it.next()
+		if err != nil {
+			return "", 0, err
+		}
+		if !ok {
+			break
+		}
+		if err := writeString(bw, ks.key); err != nil {
+			return "", 0, err
+		}
+		if _, err := bw.Write(buf[:binary.PutUvarint(buf[:], ks.seq)]); err != nil {
+			return "", 0, err
+		}
+	}
+	if err := bw.Flush(); err != nil {
+		return "", 0, err
+	}
+	var n int64
+	if st, err := f.Stat(); err == nil {
+		n = st.Size()
+		s.spilled += n
+	}
+	return path, n, f.Close()
+}
+
+// openRuns は run ファイルをまとめてキー順に読むイテレータを返す
+func openRuns(paths []string) (keySeqIter, func() error, error) {
+	var files []*os.File
+	closeAll := func() error {
+		var errs []error
+		for _, f := range files {
+			errs = append(errs, f.Close())
+		}
+		return errors.Join(errs...)
+	}
+	its := make([]keySeqIter, 0, len(paths))
+	for _, path := range paths {
+		f, err := os.Open(path)
+		if err != nil {
+			closeAll()
+			return nil, nil, err
+		}
+		files = append(files, f)
+		its = append(its, &fileIter{r: bufio.NewReaderSize(f, 1<<16)})
+	}
+	return newMergeIter(its), closeAll, nil
+}
+
+// finish は残す行を決めて、元の順序のまま write に渡す
+func (s *spillDedupe) finish(write func([]string) error) (wrote, dropped int, err error) {
+	if err := s.rowsW.Flush(); err != nil {
+		return 0, 0, err
+	}
+	if st, err := s.rows.Stat(); err == nil {
+		s.spilled += st.Size()
+	}
+
+	// 2) run（と最後のメモリ上の分）をキー順にマージ
+	runs, closeRuns, err := openRuns(s.runs())
+	if err != nil {
+		return 0, 0, err
+	}
+	sortChunk(s.chunk)
+	err = s.markKept(newMergeIter([]keySeqIter{runs, &sliceIter{items: s.chunk}}))
+	if cerr := closeRuns(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return 0, 0, err
+	}
+	s.chunk = nil
+
+	// 3) 行を読み直して出力
+	if _, err := s.rows.Seek(0, io.SeekStart); err != nil {
+		return 0, 0, err
+	}
+	br := bufio.NewReaderSize(s.rows, 1<<16)
+	for seq := uint64(0); seq < s.n; seq++ {
+		fields, err := readRecord(br)
+		if err != nil {
+			return wrote, dropped, err
+		}
+		if !s.marked(seq) {
+			dropped++
+			continue
+		}
+		if err := write(fields); err != nil {
+			return wrote, dropped, err
+		}
+		wrote++
+	}
+	return wrote, dropped, nil
+}
+
+// markKept はキーごとに最初（keep=first）か最後（keep=last）の行番号に印を付ける
+func (s *spillDedupe) markKept(it keySeqIter) error {
+	var cur keySeq
+	started := false
+	for {
+		ks, ok, err := it.next()
+		if err != nil {
+			return err
+		}
+		if !ok {
+			break
+		}
+		switch {
+		case !started || ks.key != cur.key:
+			if started {
+				s.mark(cur.seq)
+			}
+			cur, started = ks, true
+		case s.keepLast:
+			cur.seq = ks.seq // 同じキーは行番号の昇順に出てくる
+		}
+	}
+	if started {
+		s.mark(cur.seq)
+	}
+	return nil
+}
+
+// Close は一時ファイルを削除する（何度呼んでもよい）
+func (s *spillDedupe) Close() error {
+	if s.rows == nil {
+		return nil
+	}
+	err := s.rows.Close()
+	s.rows = nil
+	return errors.Join(err, os.RemoveAll(s.dir))
+}
+
+// --- run のマージ ---
+
+type keySeqIter interface {
+	next() (keySeq, bool, error)
+}
+
+type sliceIter struct {
+	items []keySeq
+	pos   int
+}
+
+func (it *sliceIter) next() (keySeq, bool, error) {
+	if it.pos >= len(it.items) {
+		return keySeq{}, false, nil
+	}
+	it.pos++
+	return it.items[it.pos-1], true, nil
+}
+
+type fileIter struct {
+	r *bufio.Reader
+}
+
+func (it *fileIter) next() (keySeq, bool, error) {
+	key, err := readString(it.r)
+	if err == io.EOF {
+		return keySeq{}, false, nil
+	}
+	if err != nil {
+		return keySeq{}, false, err
+	}
+	seq, err := binary.ReadUvarint(it.r)
+	if err != nil {
+		return keySeq{}, false, io.ErrUnexpectedEOF
+	}
+	return keySeq{key: key, seq: seq}, true, nil
+}
+
+// mergeIter は複数のキー順イテレータを (key, seq) の順にマージする
+type mergeIter struct {
+	h       mergeHeap
+	its     []keySeqIter
+	started bool
+}
+
+func newMergeIter(its []keySeqIter) *mergeIter { return &mergeIter{its: its} }
+
+func (m *mergeIter) next() (keySeq, bool, error) {
+	if !m.started {
+		m.started = true
+		for _, it := range m.its {
+			if err := m.push(it); err != nil {
+				return keySeq{}, false, err
+			}
+		}
+	}
+	if m.h.Len() == 0 {
+		return keySeq{}, false, nil
+	}
+	top := heap.Pop(&m.h).(mergeItem)
+	if err := m.push(top.it); err != nil {
+		return keySeq{}, false, err
+	}
+	return top.keySeq, true, nil
+}
+
+func (m *mergeIter) push(it keySeqIter) error {
+	ks, ok, err := it.next()
+	if err != nil {
+		return err
+	}
+	if ok {
+		heap.Push(&m.h, mergeItem{keySeq: ks, it: it})
+	}
+	return nil
+}
+
+type mergeItem struct {
+	keySeq
+	it keySeqIter
+}
+
+type mergeHeap []mergeItem
+
+func (h mergeHeap) Len() int { return len(h) }
+func (h mergeHeap) Less(i, j int) bool {
+	if h[i].key != h[j].key {
+		return h[i].key < h[j].key
+	}
+	return h[i].seq < h[j].seq
+}
+func (h mergeHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+func (h *mergeHeap) Push(x any)   { *h = append(*h, x.(mergeItem)) }
+func (h *mergeHeap) Pop() any {
+	old := *h
+	x := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return x
+}
+
+// --- 一時ファイルの書式（長さ付きの文字列。CSV を経由しないので値はそのまま戻る） ---
+
+func writeString(w *bufio.Writer, s string) error {
+	var buf [binary.MaxVarintLen64]byte
+	if _, err := w.Write(buf[:binary.PutUvarint(buf[:], uint64(len(s)))]); err != nil {
+		return err
+	}
+	_, err := w.WriteString(s)
+	return err
+}
+
+func readString(r *bufio.Reader) (string, error) {
+	n, err := binary.ReadUvarint(r)
+	if err != nil {
+		return "", err
+	}
+	b := make([]byte, n)
+	if _, err := io.ReadFull(r, b); err != nil {
+		return "", io.ErrUnexpectedEOF
+	}
+	return string(b), nil
+}
+
+func writeRecord(w *bufio.Writer, fields []string) error {
+	var buf [binary.MaxVarintLen64]byte
+	if _, err := w.Write(buf[:binary.PutUvarint(buf[:], uint64(len(fields)))]); err != nil {
+		return err
+	}
+	for _, f := range fields {
+		if err := writeString(w, f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func readRecord(r *bufio.Reader) ([]string, error) {
+	n, err := binary.ReadUvarint(r)
+	if err != nil {
+		return nil, err
+	}
+	fields := make([]string, n)
+	for i := range fields {
+		if fields[i], err = readString(r); err != nil {
+			return nil, err
+		}
+	}
+	return fields, nil
+}
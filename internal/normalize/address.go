@@ -0,0 +1,351 @@
+package normalize
+
+import (
+	_ "embed"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+// 既定の市区町村テーブル（1列目=市区町村, 2列目=都道府県）
+//
+//go:embed address_cities.tsv
+var builtinAddressCities string
+
+var prefectures = []string{
+	"北海道", "青森県", "岩手県", "宮城県", "秋田県", "山形県", "福島県",
+	"茨城県", "栃木県", "群馬県", "埼玉県", "千葉県", "東京都", "神奈川県",
+	"新潟県", "富山県", "石川県", "福井県", "山梨県", "長野県", "岐阜県", "静岡県", "愛知県",
+	"三重県", "滋賀県", "京都府", "大阪府", "兵庫県", "奈良県", "和歌山県",
+	"鳥取県", "島根県", "岡山県", "広島県", "山口県",
+	"徳島県", "香川県", "愛媛県", "高知県",
+	"福岡県", "佐賀県", "長崎県", "熊本県", "大分県", "宮崎県", "鹿児島県", "沖縄県",
+}
+
+var (
+	citiesOnce sync.Once
+	cities     map[string]string
+	citiesErr  error
+)
+
+// AddressCities は既定の市区町村テーブルを返す（呼び出し側で変更しないこと）
+func AddressCities() map[string]string {
+	citiesOnce.Do(func() {
+		cities = map[string]string{}
+		citiesErr = readAddressCities(strings.NewReader(builtinAddressCities), "builtin", cities)
+	})
+	if citiesErr != nil {
+		panic("normalize: " + citiesErr.Error())
+	}
+	return cities
+}
+
+// loadAddressCities は既定テーブルに path の内容を重ねたテーブルを返す（path が空なら既定のまま）。
+// 都道府県を空にした行は既定の行を無効にする。
+func loadAddressCities(path string) (map[string]string, error) {
+	base := AddressCities()
+	if path == "" {
+		return base, nil
+	}
+	m := make(map[string]string, len(base))
+	for k, v := range base {
+		m[k] = v
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	if err := readAddressCities(f, path, m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func readAddressCities(r io.Reader, name string, m map[string]string) error {
+	cr := csv.NewReader(r)
+	cr.Comma = '\t'
+	cr.Comment = '#'
+	cr.FieldsPerRecord = -1
+	cr.LazyQuotes = true
+	for line := 1; ; line++ {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("address cities %s: %w", name, err)
+		}
+		city := strings.TrimPrefix(strings.TrimSpace(rec[0]), "\ufeff")
+		if city == "" {
+			continue
+		}
+		pref := ""
+		if len(rec) > 1 {
+			pref = strings.TrimSpace(rec[1])
+		}
+		if pref == "" {
+			delete(m, city)
+			continue
+		}
+		if !isPrefecture(pref) {
+			return fmt.Errorf("address cities %s: line %d: unknown prefecture %q", name, line, pref)
+		}
+		m[city] = pref
+	}
+}
+
+func isPrefecture(s string) bool {
+	for _, p := range prefectures {
+		if s == p {
+			return true
+		}
+	}
+	return false
+}
+
+// prefectureOf は住所先頭の都道府県名を返す（なければ空）
+func prefectureOf(s string) string {
+	for _, p := range prefectures {
+		if strings.HasPrefix(s, p) {
+			return p
+		}
+	}
+	return ""
+}
+
+// 番地の区切り（ハイフン類。長音 ー も番地の間では区切りとして扱う）
+const addressHyphens = "-−－‐‑‒–—―ー"
+
+// addrPart は番地の 1 要素（数と、その後ろの区切り）
+type addrPart struct {
+	num    string
+	kanji  bool   // 漢数字で書かれていた
+	marker string // 丁目 | 番地 | 番 | 号 | の | - | ""
+	end    int    // この要素の終端（丁目・番地・番・号は含み、- と の は含まない）
+}
+
+// NormalizeAddress は住所の丁目・番地・号の表記をそろえる。
+//   - 1丁目2番3号 / 一丁目２−３ / 1-2-3 / 2番地の3 → 1-2-3 / 2-3（漢数字は番地の中だけ変換）
+//   - 空白は英数字どうしの間だけ残す
+//   - prefecture: add は都道府県を補い（市区町村テーブルで判定。郡があれば郡の後ろの町村で判定）、strip は取り除く
+//
+// add で都道府県を判定できない場合は、そろえた値と *ValueError を返す。
+func NormalizeAddress(s string, cityTable map[string]string, prefecture string) (string, error) {
+	s = strings.Map(func(r rune) rune {
+		if IsZenkakuDigit(r) {
+			return r - '０' + '0'
+		}
+		return r
+	}, s)
+	s = removeAddressSpaces(normalizeBlocks(s))
+
+	switch strings.ToLower(prefecture) {
+	case "strip":
+		return strings.TrimPrefix(s, prefectureOf(s)), nil
+	case "add":
+		if prefectureOf(s) != "" {
+			return s, nil
+		}
+		if cityTable == nil {
+			cityTable = AddressCities()
+		}
+		city := cityOf(s, cityTable)
+		if i := strings.Index(s, "郡"); city == "" && i > 0 {
+			city = cityOf(s[i+len("郡"):], cityTable) // 虻田郡倶知安町… は郡の後ろの町村で引く
+		}
+		if city == "" {
+			return s, &ValueError{Type: "address", Value: s, Reason: "prefecture not found"}
+		}
+		return cityTable[city] + s, nil
+	}
+	return s, nil
+}
+
+// cityOf は s の先頭に一致する最も長い市区町村名を返す（なければ空）
+func cityOf(s string, cityTable map[string]string) string {
+	best := ""
+	for city := range cityTable {
+		if len(city) > len(best) && strings.HasPrefix(s, city) {
+			best = city
+		}
+	}
+	return best
+}
+
+// normalizeBlocks は番地部分を見つけて "1-2-3" の形に置き換える
+func normalizeBlocks(s string) string {
+	rs := []rune(s)
+	var b strings.Builder
+	b.Grow(len(s))
+	for i := 0; i < len(rs); {
+		if i == 0 || !isAddressNumeral(rs[i-1]) {
+			if parts, end := scanAddressBlock(rs, i); parts != nil {
+				nums := make([]string, len(parts))
+				for k, p := range parts {
+					nums[k] = p.num
+					if p.kanji {
+						if n, ok := parseKanjiNumeral([]rune(p.num)); ok {
+							nums[k] = strconv.FormatUint(n, 10)
+						}
+					}
+				}
+				b.WriteString(strings.Join(nums, "-"))
+				i = end
+				continue
+			}
+		}
+		b.WriteRune(rs[i])
+		i++
+	}
+	return b.String()
+}
+
+func isAddressNumeral(r rune) bool {
+	if isASCIIDigit(r) {
+		return true
+	}
+	if _, ok := kanjiDigits[r]; ok {
+		return true
+	}
+	_, ok := kanjiSmallUnits[r]
+	return ok
+}
+
+// scanAddressBlock は rs[i:] 先頭の番地を読み、要素と終端を返す。番地でなければ nil。
+func scanAddressBlock(rs []rune, i int) ([]addrPart, int) {
+	var parts []addrPart
+	for j := i; ; {
+		k := j
+		for k < len(rs) && isAddressNumeral(rs[k]) {
+			k++
+		}
+		if k == j {
+			break
+		}
+		p := addrPart{num: string(rs[j:k]), kanji: !isASCIIDigit(rs[j]), end: k}
+		if p.kanji && strings.ContainsFunc(p.num, isASCIIDigit) {
+			break // 算用数字と漢数字が混ざった数は番地として扱わない
+		}
+		p.marker, k = readAddressMarker(rs, skipSpaces(rs, k))
+
+		// 一番町・号室 などは地名・建物名の一部
+		if k < len(rs) && (p.marker == "番" && strings.ContainsRune("町丁館", rs[k]) ||
+			p.marker == "号" && strings.ContainsRune("室棟館線", rs[k])) {
+			break
+		}
+		switch p.marker {
+		case "丁目", "番地", "番", "号":
+			p.end = k
+		}
+		parts = append(parts, p)
+		if p.marker == "" || p.marker == "号" {
+			break
+		}
+
+		j = skipSpaces(rs, k)
+		if p.marker == "番地" || p.marker == "番" {
+			// 2番地の3 / 2番-3
+			if m, next := readAddressMarker(rs, j); m == "の" || m == "-" {
+				j = skipSpaces(rs, next)
+			}
+		}
+		if j >= len(rs) || !isAddressNumeral(rs[j]) {
+			break
+		}
+	}
+
+	if !validAddressBlock(parts) {
+		return nil, i
+	}
+	return parts, parts[len(parts)-1].end
+}
+
+// validAddressBlock は番地として変換してよいかを判定する。
+// 丁目・番地・番・号のいずれかを含むか、算用数字を含む 2 要素以上をハイフン類でつないだもの。
+// 漢数字＋番 で始まり 号 のないもの（麻布十番）は地名とみなす。
+func validAddressBlock(parts []addrPart) bool {
+	if len(parts) == 0 {
+		return false
+	}
+	hasMarker, hasGo, hasASCII := false, false, false
+	for _, p := range parts {
+		switch p.marker {
+		case "丁目", "番地", "番":
+			hasMarker = true
+		case "号":
+			hasMarker, hasGo = true, true
+		}
+		if !p.kanji {
+			hasASCII = true
+		}
+	}
+	if parts[0].kanji && parts[0].marker == "番" && !hasGo {
+		return false
+	}
+	if hasMarker {
+		return true
+	}
+	return hasASCII && len(parts) >= 2
+}
+
+func readAddressMarker(rs []rune, k int) (string, int) {
+	if k >= len(rs) {
+		return "", k
+	}
+	rest := string(rs[k:])
+	for _, m := range []string{"丁目", "番地", "番", "号", "の", "ノ"} {
+		if strings.HasPrefix(rest, m) {
+			if m == "ノ" {
+				m = "の"
+			}
+			return m, k + len([]rune(m))
+		}
+	}
+	if strings.ContainsRune(addressHyphens, rs[k]) {
+		return "-", k + 1
+	}
+	return "", k
+}
+
+func skipSpaces(rs []rune, k int) int {
+	for k < len(rs) && unicode.IsSpace(rs[k]) {
+		k++
+	}
+	return k
+}
+
+// removeAddressSpaces は英数字どうしの間以外の空白を取り除く
+func removeAddressSpaces(s string) string {
+	rs := []rune(s)
+	var b strings.Builder
+	b.Grow(len(s))
+	for i := 0; i < len(rs); i++ {
+		if !unicode.IsSpace(rs[i]) {
+			b.WriteRune(rs[i])
+			continue
+		}
+		j := skipSpaces(rs, i)
+		if i > 0 && j < len(rs) && isASCIIAlnum(rs[i-1]) && isASCIIAlnum(rs[j]) {
+			b.WriteByte(' ')
+		}
+		i = j - 1
+	}
+	return b.String()
+}
+
+func isASCIIAlnum(r rune) bool {
+	return r < utf8.RuneSelf && (unicode.IsLetter(r) || unicode.IsDigit(r))
+}
+
+func init() {
+	registerType("address", func(s string, o *Options) (string, error) {
+		return NormalizeAddress(s, o.addressCities, o.AddressPrefecture)
+	})
+}
@@ -0,0 +1,106 @@
+package normalize
+
+import (
+	"regexp"
+	"strings"
+)
+
+// legalForm は法人格の表記ゆれと正規形
+type legalForm struct {
+	canonical string
+	english   bool           // 英語表記（後ろに空白 1 つで付ける）
+	prefix    *regexp.Regexp // 名前の前に付く表記（英語表記は nil）
+	suffix    *regexp.Regexp
+}
+
+// 法人格の一覧（長い表記を先に照合するため、包含関係のあるものは長い方を前に置く）
+var legalForms = []legalForm{
+	japaneseForm("一般社団法人", `一般社団法人|[(（]\s*一社\s*[)）]`),
+	japaneseForm("一般財団法人", `一般財団法人|[(（]\s*一財\s*[)）]`),
+	japaneseForm("公益社団法人", `公益社団法人|[(（]\s*公社\s*[)）]`),
+	japaneseForm("公益財団法人", `公益財団法人|[(（]\s*公財\s*[)）]`),
+	japaneseForm("特定非営利活動法人", `特定非営利活動法人|NPO\s*法人|[(（]\s*特非\s*[)）]`),
+	japaneseForm("社会福祉法人", `社会福祉法人|[(（]\s*福\s*[)）]`),
+	japaneseForm("株式会社", `株式会社|[(（]\s*株\s*[)）]|㈱`),
+	japaneseForm("有限会社", `有限会社|[(（]\s*有\s*[)）]|㈲`),
+	japaneseForm("合同会社", `合同会社|[(（]\s*同\s*[)）]`),
+	japaneseForm("合資会社", `合資会社|[(（]\s*資\s*[)）]|㈾`),
+	japaneseForm("合名会社", `合名会社|[(（]\s*名\s*[)）]|㈴`),
+	japaneseForm("社団法人", `社団法人|[(（]\s*社\s*[)）]|㈳`),
+	japaneseForm("財団法人", `財団法人|[(（]\s*財\s*[)）]|㈶`),
+	japaneseForm("医療法人", `医療法人|[(（]\s*医\s*[)）]`),
+	japaneseForm("学校法人", `学校法人|[(（]\s*学\s*[)）]|㈻`),
+	// KK（Kabushiki Kaisha）は株式会社として扱う
+	{canonical: "株式会社", suffix: englishSuffix(`k\.?\s*k\.?`)},
+	{canonical: "Co., Ltd.", english: true, suffix: englishSuffix(`co\.?\s*,?\s*ltd\.?|company\s*,?\s*limited`)},
+	{canonical: "Inc.", english: true, suffix: englishSuffix(`inc\.?|incorporated`)},
+	{canonical: "Corp.", english: true, suffix: englishSuffix(`corp\.?|corporation`)},
+	{canonical: "LLC", english: true, suffix: englishSuffix(`l\.?\s*l\.?\s*c\.?`)},
+	{canonical: "Ltd.", english: true, suffix: englishSuffix(`ltd\.?|limited`)},
+}
+
+func japaneseForm(canonical, pattern string) legalForm {
+	return legalForm{
+		canonical: canonical,
+		prefix:    regexp.MustCompile(`^\s*(?:` + pattern + `)\s*`),
+		suffix:    regexp.MustCompile(`\s*(?:` + pattern + `)\s*$`),
+	}
+}
+
+// englishSuffix は空白かカンマの後ろに付く英語の法人格（大小文字は区別しない）
+func englishSuffix(pattern string) *regexp.Regexp {
+	return regexp.MustCompile(`(?i)[\s,]+(?:` + pattern + `)\s*$`)
+}
+
+// NormalizeCompanyName は名前の前後の法人格（前株・後株）を見つけてそろえる。
+//   - canonical: ㈱ / (株) / KK → 株式会社、Co.,Ltd / company limited → Co., Ltd. など正規形に（位置は保つ）
+//   - strip: 法人格を取り除く（重複排除キー向け）
+//
+// 戻り値の name は法人格を除いた名前（法人格だけの値なら空）。
+func NormalizeCompanyName(s, mode string) (out, name string) {
+	name = strings.TrimSpace(s)
+	var pre, suf *legalForm
+	for i := range legalForms {
+		f := &legalForms[i]
+		if f.prefix == nil {
+			continue
+		}
+		if loc := f.prefix.FindStringIndex(name); loc != nil {
+			pre, name = f, name[loc[1]:]
+			break
+		}
+	}
+	for i := range legalForms {
+		f := &legalForms[i]
+		if loc := f.suffix.FindStringIndex(name); loc != nil && loc[0] > 0 {
+			suf, name = f, strings.TrimRight(name[:loc[0]], " ,")
+			break
+		}
+	}
+
+	if strings.EqualFold(mode, "strip") {
+		return name, name
+	}
+	var b strings.Builder
+	if pre != nil {
+		b.WriteString(pre.canonical)
+	}
+	b.WriteString(name)
+	if suf != nil {
+		if suf.english {
+			b.WriteByte(' ')
+		}
+		b.WriteString(suf.canonical)
+	}
+	return b.String(), name
+}
+
+func init() {
+	// 法人格は company_legal_form 工程でそろえ済み。法人格だけの値（"株式会社" のみ等）はエラーにする
+	registerType("company_name", func(s string, o *Options) (string, error) {
+		if _, name := NormalizeCompanyName(s, "strip"); name == "" {
+			return s, &ValueError{Type: "company_name", Value: s, Reason: "legal form only"}
+		}
+		return s, nil
+	})
+}
@@ -0,0 +1,138 @@
+package normalize
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	rePhoneLabel    = regexp.MustCompile(`(?i)^(?:tel|phone|電話(?:番号)?)\s*[:：.]?\s*`)
+	rePhoneTrunk    = regexp.MustCompile(`^\+81\s*[(（]\s*0\s*[)）]`) // +81 (0)3-… の (0)
+	rePostalLabel   = regexp.MustCompile(`^(?:〒|郵便番号\s*[:：]?)\s*`)
+	reEmail         = regexp.MustCompile(`^[^@\s]+@[^@\s]+\.[^@\s]+$`)
+	phoneSeparators = " \t-‐‑‒–—―−－ー().（）"
+)
+
+// toASCIIDigits は全角数字を半角にする
+func toASCIIDigits(s string) string {
+	return strings.Map(func(r rune) rune {
+		if IsZenkakuDigit(r) {
+			return r - '０' + '0'
+		}
+		return r
+	}, s)
+}
+
+func allDigits(s string) bool {
+	for _, r := range s {
+		if !isASCIIDigit(r) {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// NormalizePhone は日本の電話番号を 0 始まりの数字列にそろえてから format で出力する。
+//   - format: digits(既定: 0312345678) | hyphen(03-1234-5678) | e164(+81312345678)
+//   - 区切り（空白・ハイフン類・カッコ・ドット）、先頭の TEL: 等を取り除き、+81 は 0 に置き換える
+//
+// 桁数が合わない番号は *ValueError を返す。
+func NormalizePhone(s, format string) (string, error) {
+	raw := s
+	s = rePhoneLabel.ReplaceAllString(strings.TrimSpace(toASCIIDigits(s)), "")
+	s = rePhoneTrunk.ReplaceAllString(s, "+81")
+	s = removeChars(s, phoneSeparators)
+	switch {
+	case strings.HasPrefix(s, "+81"):
+		s = "0" + strings.TrimPrefix(s[3:], "0")
+	case strings.HasPrefix(s, "0081"):
+		s = "0" + strings.TrimPrefix(s[4:], "0")
+	}
+	if !allDigits(s) || s[0] != '0' {
+		return "", &ValueError{Type: "phone", Value: raw, Reason: "not a Japanese phone number"}
+	}
+
+	mobile := len(s) >= 3 && (s[:3] == "050" || s[:3] == "070" || s[:3] == "080" || s[:3] == "090")
+	switch {
+	case len(s) == 11 && (mobile || strings.HasPrefix(s, "0800")):
+	case len(s) == 10 && !mobile:
+	default:
+		return "", &ValueError{Type: "phone", Value: raw, Reason: "invalid number of digits"}
+	}
+
+	switch strings.ToLower(format) {
+	case "hyphen":
+		return hyphenatePhone(s), nil
+	case "e164":
+		return "+81" + s[1:], nil
+	}
+	return s, nil
+}
+
+// hyphenatePhone は市外局番の桁で区切る。
+// 固定電話は 03/06 を 2 桁、それ以外を 3 桁の市外局番とみなす（04-2xxx や 4〜5 桁の市外局番は正確でない）。
+func hyphenatePhone(s string) string {
+	split := func(a, b int) string { return s[:a] + "-" + s[a:a+b] + "-" + s[a+b:] }
+	switch {
+	case len(s) == 11 && strings.HasPrefix(s, "0800"):
+		return split(4, 3)
+	case len(s) == 11:
+		return split(3, 4)
+	case strings.HasPrefix(s, "0120"), strings.HasPrefix(s, "0570"), strings.HasPrefix(s, "0990"):
+		return split(4, 3)
+	case strings.HasPrefix(s, "03"), strings.HasPrefix(s, "06"):
+		return split(2, 4)
+	}
+	return split(3, 3)
+}
+
+// NormalizePostalCode は郵便番号（〒123-4567 / 1234567 / １２３－４５６７）を
+// format: digits(既定: 1234567) | hyphen(123-4567) にそろえる。7 桁でなければ *ValueError。
+func NormalizePostalCode(s, format string) (string, error) {
+	raw := s
+	s = rePostalLabel.ReplaceAllString(strings.TrimSpace(toASCIIDigits(s)), "")
+	s = removeChars(s, phoneSeparators)
+	if len(s) != 7 || !allDigits(s) {
+		return "", &ValueError{Type: "postal_code", Value: raw, Reason: "not a 7-digit postal code"}
+	}
+	if strings.EqualFold(format, "hyphen") {
+		return s[:3] + "-" + s[3:], nil
+	}
+	return s, nil
+}
+
+// NormalizeEmail はメールアドレスの前後の空白・<>・mailto: を取り除き、ドメインを小文字にする。
+// foldGmail なら gmail.com / googlemail.com のローカル部を小文字にし、"." と "+" 以降を取り除く。
+func NormalizeEmail(s string, foldGmail bool) (string, error) {
+	raw := s
+	s = strings.TrimSpace(s)
+	s = strings.TrimSuffix(strings.TrimPrefix(s, "<"), ">")
+	if len(s) >= 7 && strings.EqualFold(s[:7], "mailto:") {
+		s = s[7:]
+	}
+	s = strings.TrimSpace(s)
+	if !reEmail.MatchString(s) {
+		return "", &ValueError{Type: "email", Value: raw, Reason: "not an email address"}
+	}
+	at := strings.LastIndexByte(s, '@')
+	local, domain := s[:at], strings.ToLower(s[at+1:])
+	if foldGmail && (domain == "gmail.com" || domain == "googlemail.com") {
+		if i := strings.IndexByte(local, '+'); i >= 0 {
+			local = local[:i]
+		}
+		local = strings.ToLower(strings.ReplaceAll(local, ".", ""))
+		domain = "gmail.com"
+		if local == "" {
+			return "", &ValueError{Type: "email", Value: raw, Reason: "empty local part"}
+		}
+	}
+	return local + "@" + domain, nil
+}
+
+func init() {
+	registerType("phone", func(s string, o *Options) (string, error) { return NormalizePhone(s, o.PhoneFormat) })
+	registerType("postal_code", func(s string, o *Options) (string, error) {
+		return NormalizePostalCode(s, o.PostalCodeFormat)
+	})
+	registerType("email", func(s string, o *Options) (string, error) { return NormalizeEmail(s, o.EmailFoldGmail) })
+}
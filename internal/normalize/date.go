@@ -0,0 +1,104 @@
+package normalize
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 元号（新しい順）。開始日より前の日付はその元号では表せない
+var eras = []struct {
+	name, abbr string
+	start      time.Time
+}{
+	{"令和", "R", time.Date(2019, 5, 1, 0, 0, 0, 0, time.UTC)},
+	{"平成", "H", time.Date(1989, 1, 8, 0, 0, 0, 0, time.UTC)},
+	{"昭和", "S", time.Date(1926, 12, 25, 0, 0, 0, 0, time.UTC)},
+	{"大正", "T", time.Date(1912, 7, 30, 0, 0, 0, 0, time.UTC)},
+	{"明治", "M", time.Date(1868, 1, 1, 0, 0, 0, 0, time.UTC)}, // 改暦前は旧暦のため年初から扱う
+}
+
+// 元号の合字（NFKC を通さない場合用）
+var eraLigatures = strings.NewReplacer("㋿", "令和", "㍻", "平成", "㍼", "昭和", "㍽", "大正", "㍾", "明治")
+
+var (
+	reEraDate     = regexp.MustCompile(`^(明治|大正|昭和|平成|令和|[MTSHRmtshr])\s*(元|\d{1,2})\s*(?:年|[./-])\s*(\d{1,2})\s*(?:月|[./-])\s*(\d{1,2})\s*日?$`)
+	reWesternDate = regexp.MustCompile(`^(\d{4})\s*(?:年|[./-])\s*(\d{1,2})\s*(?:月|[./-])\s*(\d{1,2})\s*日?$`)
+	reCompactDate = regexp.MustCompile(`^(\d{4})(\d{2})(\d{2})$`)
+	reWeekday     = regexp.MustCompile(`\s*[(（][^()（）]{1,3}[)）]$`) // 末尾の (月) / （火） / (Mon)
+)
+
+// DefaultDateLayout は date_layout 未指定時の出力書式（ISO 8601）
+const DefaultDateLayout = "2006-01-02"
+
+// ParseDate は和暦（令和6年4月1日, R6.4.1, H31/4/30, 平成元年）と
+// 西暦（2024年4月1日, 2024/4/1, 2024-04-01, 20240401）の日付を解釈する。
+// 漢数字（令和六年四月一日）・全角数字・末尾の曜日も受け付ける。
+func ParseDate(s string) (time.Time, error) {
+	raw := s
+	s = eraLigatures.Replace(strings.TrimSpace(s))
+	s = reWeekday.ReplaceAllString(NormalizeNumbers(s), "")
+
+	if m := reEraDate.FindStringSubmatch(s); m != nil {
+		year := 1
+		if m[2] != "元" {
+			year, _ = strconv.Atoi(m[2])
+		}
+		for i, e := range eras {
+			if m[1] != e.name && !strings.EqualFold(m[1], e.abbr) {
+				continue
+			}
+			t, err := makeDate(e.start.Year()+year-1, m[3], m[4], raw)
+			if err != nil {
+				return time.Time{}, err
+			}
+			if year < 1 || t.Before(e.start) {
+				return time.Time{}, &ValueError{Type: "date", Value: raw, Reason: "before the start of " + e.name}
+			}
+			if i > 0 && !t.Before(eras[i-1].start) {
+				return time.Time{}, &ValueError{Type: "date", Value: raw, Reason: "after the end of " + e.name}
+			}
+			return t, nil
+		}
+	}
+	if m := reWesternDate.FindStringSubmatch(s); m != nil {
+		y, _ := strconv.Atoi(m[1])
+		return makeDate(y, m[2], m[3], raw)
+	}
+	if m := reCompactDate.FindStringSubmatch(s); m != nil {
+		y, _ := strconv.Atoi(m[1])
+		return makeDate(y, m[2], m[3], raw)
+	}
+	return time.Time{}, &ValueError{Type: "date", Value: raw, Reason: "unrecognized date format"}
+}
+
+// makeDate は存在しない日付（2月30日など）をエラーにする
+func makeDate(year int, month, day, raw string) (time.Time, error) {
+	m, _ := strconv.Atoi(month)
+	d, _ := strconv.Atoi(day)
+	t := time.Date(year, time.Month(m), d, 0, 0, 0, 0, time.UTC)
+	if t.Year() != year || int(t.Month()) != m || t.Day() != d {
+		return time.Time{}, &ValueError{Type: "date", Value: raw, Reason: "invalid date"}
+	}
+	return t, nil
+}
+
+// dateLayout は date_layout の別名（iso8601）を Go のレイアウトに展開する
+func dateLayout(layout string) string {
+	switch strings.ToLower(strings.TrimSpace(layout)) {
+	case "", "iso8601", "iso":
+		return DefaultDateLayout
+	}
+	return layout
+}
+
+func init() {
+	registerType("date", func(s string, o *Options) (string, error) {
+		t, err := ParseDate(s)
+		if err != nil {
+			return "", err
+		}
+		return t.Format(dateLayout(o.DateLayout)), nil
+	})
+}
@@ -0,0 +1,81 @@
+package normalize
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// 分解しても基底文字にならない文字の置き換え
+var diacriticSpecial = map[rune]string{
+	'ß': "ss", 'ẞ': "SS",
+	'æ': "ae", 'Æ': "AE",
+	'œ': "oe", 'Œ': "OE",
+	'ø': "o", 'Ø': "O",
+	'đ': "d", 'Đ': "D",
+	'ð': "d", 'Ð': "D",
+	'þ': "th", 'Þ': "TH",
+	'ł': "l", 'Ł': "L",
+	'ħ': "h", 'Ħ': "H",
+	'ŧ': "t", 'Ŧ': "T",
+	'ı': "i",
+}
+
+var diacriticScripts = map[string]*unicode.RangeTable{
+	"latin":    unicode.Latin,
+	"greek":    unicode.Greek,
+	"cyrillic": unicode.Cyrillic,
+}
+
+// resolveDiacriticScripts は diacritics_scripts の名前を文字種表に変換する（空なら 3 種すべて）
+func resolveDiacriticScripts(names []string) ([]*unicode.RangeTable, error) {
+	if len(names) == 0 {
+		return []*unicode.RangeTable{unicode.Latin, unicode.Greek, unicode.Cyrillic}, nil
+	}
+	tables := make([]*unicode.RangeTable, 0, len(names))
+	for _, n := range names {
+		t, ok := diacriticScripts[strings.ToLower(strings.TrimSpace(n))]
+		if !ok {
+			return nil, fmt.Errorf("unknown diacritics_scripts: %q (use latin, greek or cyrillic)", n)
+		}
+		tables = append(tables, t)
+	}
+	return tables, nil
+}
+
+// StripDiacritics は指定の文字種（既定: ラテン・ギリシャ・キリル文字）に付いた結合文字を取り除く。
+// Müller → Muller, Dvořák → Dvorak, ß → ss, æ → ae, ø → o。
+// 対象外の文字（かなの濁点・半濁点など）に付いた結合文字はそのまま残す。
+func StripDiacritics(s string, scripts []*unicode.RangeTable) string {
+	if scripts == nil {
+		scripts, _ = resolveDiacriticScripts(nil)
+	}
+	var b strings.Builder
+	b.Grow(len(s))
+	inScope := false // 直前の基底文字が対象の文字種か
+	for _, r := range s {
+		if unicode.Is(unicode.Mn, r) {
+			if !inScope {
+				b.WriteRune(r)
+			}
+			continue
+		}
+		inScope = unicode.In(r, scripts...)
+		if !inScope {
+			b.WriteRune(r)
+			continue
+		}
+		if rep, ok := diacriticSpecial[r]; ok {
+			b.WriteString(rep)
+			continue
+		}
+		for _, d := range norm.NFD.String(string(r)) {
+			if !unicode.Is(unicode.Mn, d) {
+				b.WriteRune(d)
+			}
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,203 @@
+package normalize
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// Dictionary は表記ゆれ → 正規形の置換辞書。トライ木で最長一致を引くため、
+// 辞書の大きさに関わらず 1 文字あたりの探索は「最長キー長」までで済む。
+type Dictionary struct {
+	root *trieNode
+	size int
+}
+
+type trieNode struct {
+	children map[rune]*trieNode
+	to       string
+	terminal bool
+}
+
+// DictionaryFile は Options から読み込む辞書ファイルの指定
+type DictionaryFile struct {
+	Path  string
+	Token bool // true: 語単位（前後が英数字でない位置）でのみ置換 / false: 最長一致の部分文字列
+}
+
+type loadedDictionary struct {
+	dict  *Dictionary
+	token bool
+}
+
+// 同じファイルを複数の列・プロファイルで使っても読み込みは 1 回
+var dictCache sync.Map // map[string]*Dictionary（絶対パス → 辞書）
+
+func NewDictionary() *Dictionary {
+	return &Dictionary{root: &trieNode{}}
+}
+
+// Add は from → to の対応を登録する（同じ from は後勝ち）
+func (d *Dictionary) Add(from, to string) {
+	if from == "" {
+		return
+	}
+	n := d.root
+	for _, r := range from {
+		if n.children == nil {
+			n.children = map[rune]*trieNode{}
+		}
+		next, ok := n.children[r]
+		if !ok {
+			next = &trieNode{}
+			n.children[r] = next
+		}
+		n = next
+	}
+	if !n.terminal {
+		d.size++
+	}
+	n.terminal = true
+	n.to = to
+}
+
+// Len は登録済みの対応数
+func (d *Dictionary) Len() int {
+	return d.size
+}
+
+// Replace は左から順に最長一致したキーを正規形に置き換える
+func (d *Dictionary) Replace(s string, token bool) string {
+	if d == nil || d.size == 0 || s == "" {
+		return s
+	}
+	rs := []rune(s)
+	var b strings.Builder
+	b.Grow(len(s))
+	for i := 0; i < len(rs); {
+		end, to := d.longest(rs, i, token)
+		if end < 0 {
+			b.WriteRune(rs[i])
+			i++
+			continue
+		}
+		b.WriteString(to)
+		i = end
+	}
+	return b.String()
+}
+
+// longest は rs[i:] から始まる最長一致の終端（排他的）と置換後の文字列を返す。一致なしなら -1。
+func (d *Dictionary) longest(rs []rune, i int, token bool) (int, string) {
+	if token && i > 0 && isWordRune(rs[i-1]) && isWordRune(rs[i]) {
+		return -1, ""
+	}
+	end, to := -1, ""
+	n := d.root
+	for j := i; j < len(rs); j++ {
+		next, ok := n.children[rs[j]]
+		if !ok {
+			break
+		}
+		n = next
+		if !n.terminal {
+			continue
+		}
+		if token && j+1 < len(rs) && isWordRune(rs[j]) && isWordRune(rs[j+1]) {
+			continue
+		}
+		end, to = j+1, n.to
+	}
+	return end, to
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// LoadDictionary は TSV（拡張子 .tsv）または CSV の辞書を読み込む。
+// 1 列目 = 表記ゆれ、2 列目 = 正規形。空行と # で始まる行は無視する。
+func LoadDictionary(path string) (*Dictionary, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	if d, ok := dictCache.Load(abs); ok {
+		return d.(*Dictionary), nil
+	}
+
+	f, err := os.Open(abs)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+	if strings.EqualFold(filepath.Ext(abs), ".tsv") {
+		r.Comma = '\t'
+	}
+	r.Comment = '#'
+	r.FieldsPerRecord = -1
+	r.LazyQuotes = true
+
+	d := NewDictionary()
+	for line := 1; ; line++ {
+		rec, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("dictionary %s: %w", path, err)
+		}
+		if line == 1 && len(rec) > 0 {
+			rec[0] = strings.TrimPrefix(rec[0], "\ufeff")
+		}
+		if len(rec) == 1 && strings.TrimSpace(rec[0]) == "" {
+			continue
+		}
+		if len(rec) < 2 {
+			return nil, fmt.Errorf("dictionary %s: line %d: want 2 columns (from, to), got %d", path, line, len(rec))
+		}
+		d.Add(rec[0], rec[1])
+	}
+
+	dictCache.Store(abs, d)
+	return d, nil
+}
+
+func loadDictionaries(files []DictionaryFile) ([]loadedDictionary, error) {
+	if len(files) == 0 {
+		return nil, nil
+	}
+	out := make([]loadedDictionary, 0, len(files))
+	for _, f := range files {
+		d, err := LoadDictionary(f.Path)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, loadedDictionary{dict: d, token: f.Token})
+	}
+	return out, nil
+}
+
+func applyDictionaries(s string, o *Options) string {
+	dicts := o.dictionaries
+	if dicts == nil {
+		// Prepare 未実行時はその場で読み込む（読めない辞書は飛ばす）
+		for _, f := range o.Dictionaries {
+			if d, err := LoadDictionary(f.Path); err == nil {
+				s = d.Replace(s, f.Token)
+			}
+		}
+		return s
+	}
+	for _, ld := range dicts {
+		s = ld.dict.Replace(s, ld.token)
+	}
+	return s
+}
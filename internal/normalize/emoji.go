@@ -0,0 +1,232 @@
+package normalize
+
+import (
+	"bufio"
+	_ "embed"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/rivo/uniseg"
+)
+
+// 絵文字の Unicode プロパティ表（emoji-data.txt 形式）。
+// Unicode の emoji-data.txt 全体から必要なプロパティを抜き出したもの。更新は go generate で行う。
+//
+//go:generate go run gen_emoji_data.go
+//go:embed emoji_data.txt
+var emojiDataTxt string
+
+// 絵文字 → CLDR 短縮名。CLDR の annotations から gen_emoji_data.go で生成する（go generate）。
+//
+//go:embed emoji_names.tsv
+var emojiNamesTsv string
+
+const (
+	runeZWJ    = '\u200D'
+	runeVS15   = '\uFE0E' // 文字表示
+	runeVS16   = '\uFE0F' // 絵文字表示
+	runeKeycap = '\u20E3'
+
+	defaultEmojiPlaceholder = "[emoji]"
+)
+
+type runeRange struct{ lo, hi rune }
+
+type runeRanges []runeRange
+
+func (rs runeRanges) contains(r rune) bool {
+	i := sort.Search(len(rs), func(i int) bool { return rs[i].hi >= r })
+	return i < len(rs) && rs[i].lo <= r
+}
+
+var (
+	emojiOnce         sync.Once
+	emojiPresentation runeRanges
+	extPictographic   runeRanges
+	emojiModifier     runeRanges
+	emojiNames        map[string]string
+)
+
+var skinToneNames = map[rune]string{
+	0x1F3FB: "light skin tone",
+	0x1F3FC: "medium-light skin tone",
+	0x1F3FD: "medium skin tone",
+	0x1F3FE: "medium-dark skin tone",
+	0x1F3FF: "dark skin tone",
+}
+
+func loadEmojiTables() {
+	emojiOnce.Do(func() {
+		props := map[string]*runeRanges{
+			"Emoji_Presentation":    &emojiPresentation,
+			"Extended_Pictographic": &extPictographic,
+			"Emoji_Modifier":        &emojiModifier,
+		}
+		sc := bufio.NewScanner(strings.NewReader(emojiDataTxt))
+		for sc.Scan() {
+			line, _, _ := strings.Cut(sc.Text(), "#")
+			cps, prop, ok := strings.Cut(line, ";")
+			if !ok {
+				continue
+			}
+			dst, ok := props[strings.TrimSpace(prop)]
+			if !ok {
+				continue
+			}
+			lo, hi, isRange := strings.Cut(strings.TrimSpace(cps), "..")
+			if !isRange {
+				hi = lo
+			}
+			*dst = append(*dst, runeRange{mustHexRune(lo), mustHexRune(hi)})
+		}
+		for _, rs := range props {
+			sort.Slice(*rs, func(i, j int) bool { return (*rs)[i].lo < (*rs)[j].lo })
+		}
+
+		emojiNames = map[string]string{}
+		sc = bufio.NewScanner(strings.NewReader(emojiNamesTsv))
+		for sc.Scan() {
+			line := sc.Text()
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			cps, name, ok := strings.Cut(line, "\t")
+			if !ok {
+				panic("normalize: bad emoji_names.tsv line: " + line)
+			}
+			var b strings.Builder
+			for _, cp := range strings.Fields(cps) {
+				b.WriteRune(mustHexRune(cp))
+			}
+			emojiNames[b.String()] = name
+		}
+	})
+}
+
+func mustHexRune(s string) rune {
+	n, err := strconv.ParseUint(strings.TrimSpace(s), 16, 32)
+	if err != nil {
+		panic(fmt.Sprintf("normalize: bad code point %q in emoji table", s))
+	}
+	return rune(n)
+}
+
+// IsEmojiCluster は書記素クラスタ 1 つが絵文字として表示されるものかを判定する。
+// ☎ ✓ のような文字表示が既定の記号は、U+FE0F が付かない限り絵文字とみなさない。
+func IsEmojiCluster(cluster string) bool {
+	loadEmojiTables()
+	rs := []rune(cluster)
+	if len(rs) == 0 {
+		return false
+	}
+	var hasVS16, hasVS15, hasZWJ, hasKeycap, hasModifier, hasPict bool
+	regional := 0
+	for _, r := range rs {
+		switch {
+		case r == runeVS16:
+			hasVS16 = true
+		case r == runeVS15:
+			hasVS15 = true
+		case r == runeZWJ:
+			hasZWJ = true
+		case r == runeKeycap:
+			hasKeycap = true
+		case emojiModifier.contains(r):
+			hasModifier = true
+		case r >= 0x1F1E6 && r <= 0x1F1FF:
+			regional++
+		}
+		if extPictographic.contains(r) {
+			hasPict = true
+		}
+	}
+	base := rs[0]
+	switch {
+	case hasKeycap:
+		return true
+	case regional > 0:
+		return true
+	case hasModifier:
+		return true
+	case hasZWJ && hasPict:
+		return true
+	case hasVS15:
+		return false
+	case emojiPresentation.contains(base):
+		return true
+	case hasVS16 && extPictographic.contains(base):
+		return true
+	}
+	// ZWJ / VS-16 だけが孤立して残ったもの
+	for _, r := range rs {
+		if r != runeZWJ && r != runeVS16 {
+			return false
+		}
+	}
+	return true
+}
+
+// EmojiName は絵文字クラスタの CLDR 短縮名を返す（表にない場合は ok=false）
+func EmojiName(cluster string) (string, bool) {
+	loadEmojiTables()
+	key := strings.ReplaceAll(cluster, string(runeVS16), "")
+	if name, ok := emojiNames[key]; ok {
+		return name, true
+	}
+	// 肌色修飾子付きは「基本の名前: 肌色」
+	var tones []string
+	base := strings.Map(func(r rune) rune {
+		if t, ok := skinToneNames[r]; ok {
+			tones = append(tones, t)
+			return -1
+		}
+		return r
+	}, key)
+	if len(tones) > 0 {
+		if name, ok := emojiNames[base]; ok {
+			return name + ": " + strings.Join(tones, ", "), true
+		}
+	}
+	return "", false
+}
+
+// ReplaceEmoji は絵文字の書記素クラスタを repl の結果で置き換える（ZWJ 連結・国旗・肌色も 1 つとして扱う）
+func ReplaceEmoji(s string, repl func(cluster string) string) string {
+	var b strings.Builder
+	b.Grow(len(s))
+	state := -1
+	rest := s
+	var cluster string
+	for len(rest) > 0 {
+		cluster, rest, _, state = uniseg.FirstGraphemeClusterInString(rest, state)
+		if IsEmojiCluster(cluster) {
+			b.WriteString(repl(cluster))
+			continue
+		}
+		b.WriteString(cluster)
+	}
+	return b.String()
+}
+
+func applyEmoji(s string, o *Options) string {
+	placeholder := o.EmojiPlaceholder
+	if placeholder == "" {
+		placeholder = defaultEmojiPlaceholder
+	}
+	switch strings.ToLower(o.EmojiMode) {
+	case "replace":
+		return ReplaceEmoji(s, func(string) string { return placeholder })
+	case "name":
+		return ReplaceEmoji(s, func(c string) string {
+			if name, ok := EmojiName(c); ok {
+				return ":" + name + ":"
+			}
+			return placeholder
+		})
+	default:
+		return ReplaceEmoji(s, func(string) string { return "" })
+	}
+}
@@ -0,0 +1,139 @@
+//go:build ignore
+
+// gen_address_cities は総務省「全国地方公共団体コード」の表から address_cities.tsv を生成する。
+// 表（Excel）の 2 枚のシート（市区町村・政令指定都市の区）を CSV で保存し、続けて渡す。
+//
+//	go run gen_address_cities.go -src 000925835.csv -src 000925836.csv
+//
+// 都道府県名（漢字）・市区町村名（漢字）の列だけを使う。政令指定都市の区（横浜市鶴見区）は区名（鶴見区）で載せる。
+// 同名の市区町村が複数の都道府県にあるものは載せない。
+package main
+
+import (
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"slices"
+	"strings"
+)
+
+// 表の基準日（ヘッダーに書く）
+const asOf = "2024-01-01"
+
+type srcList []string
+
+func (s *srcList) String() string     { return strings.Join(*s, ",") }
+func (s *srcList) Set(v string) error { *s = append(*s, v); return nil }
+
+func main() {
+	var srcs srcList
+	flag.Var(&srcs, "src", "全国地方公共団体コードの CSV（複数可）")
+	out := flag.String("o", "address_cities.tsv", "出力先")
+	flag.Parse()
+	if len(srcs) == 0 {
+		log.Fatal("-src is required")
+	}
+
+	var prefs []string               // 出現順
+	cities := map[string][]string{}  // 都道府県 → 市区町村（出現順）
+	prefsOf := map[string][]string{} // 市区町村 → 都道府県
+	for _, src := range srcs {
+		if err := read(src, func(pref, city string) {
+			if !slices.Contains(prefs, pref) {
+				prefs = append(prefs, pref)
+			}
+			if slices.Contains(cities[pref], city) {
+				return
+			}
+			cities[pref] = append(cities[pref], city)
+			if !slices.Contains(prefsOf[city], pref) {
+				prefsOf[city] = append(prefsOf[city], pref)
+			}
+		}); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	var dup []string
+	for _, pref := range prefs {
+		for _, city := range cities[pref] {
+			if len(prefsOf[city]) > 1 && !slices.Contains(dup, city) {
+				dup = append(dup, city)
+			}
+		}
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# 市区町村 → 都道府県（type: address の address_prefecture: add で使う既定テーブル）\n")
+	fmt.Fprintf(&b, "# 総務省「全国地方公共団体コード」（%s 現在）から gen_address_cities.go で生成。政令指定都市の区は区名で載せる。\n", asOf)
+	b.WriteString("# 1列目=市区町村名（住所の先頭に現れる形）, 2列目=都道府県。address_city_table で追加・上書きできる。\n")
+	b.WriteString("# 同名の市区町村が複数の都道府県にあるものは載せない。2列目を空にすると既定の行を無効化できる。\n")
+	fmt.Fprintf(&b, "# 除外: %s\n", strings.Join(dup, " "))
+	n := 0
+	for _, pref := range prefs {
+		fmt.Fprintf(&b, "\n# %s\n", pref)
+		for _, city := range cities[pref] {
+			if len(prefsOf[city]) > 1 {
+				continue
+			}
+			fmt.Fprintf(&b, "%s\t%s\n", city, pref)
+			n++
+		}
+	}
+	if err := os.WriteFile(*out, []byte(b.String()), 0o644); err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("%s: %d entries, %d excluded", *out, n, len(dup))
+}
+
+// read は CSV の各行の都道府県名・市区町村名を fn に渡す（都道府県の行は飛ばす）
+func read(src string, fn func(pref, city string)) error {
+	f, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = -1
+	header, err := r.Read()
+	if err != nil {
+		return fmt.Errorf("%s: %w", src, err)
+	}
+	prefCol, cityCol := column(header, "都道府県名（漢字）"), column(header, "市区町村名（漢字）")
+	if prefCol < 0 || cityCol < 0 {
+		return fmt.Errorf("%s: 都道府県名（漢字）/市区町村名（漢字） の列がない", src)
+	}
+	for {
+		rec, err := r.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", src, err)
+		}
+		if len(rec) <= max(prefCol, cityCol) {
+			continue
+		}
+		pref, city := strings.TrimSpace(rec[prefCol]), strings.TrimSpace(rec[cityCol])
+		if pref == "" || city == "" {
+			continue
+		}
+		// 政令指定都市の区（札幌市中央区）は区名だけにする
+		if i := strings.Index(city, "市"); i > 0 && strings.HasSuffix(city, "区") && i+len("市") < len(city) {
+			city = city[i+len("市"):]
+		}
+		fn(pref, city)
+	}
+}
+
+func column(header []string, name string) int {
+	for i, h := range header {
+		if strings.TrimSpace(strings.TrimPrefix(h, "\ufeff")) == name {
+			return i
+		}
+	}
+	return -1
+}
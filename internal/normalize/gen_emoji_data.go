@@ -0,0 +1,276 @@
+//go:build ignore
+
+// gen_emoji_data は Unicode の emoji-data.txt から絵文字判定に使うプロパティだけを抜き出し、
+// 隣り合う範囲をまとめて emoji_data.txt を生成する。
+// あわせて CLDR の annotations（en.xml と annotationsDerived/en.xml）の短縮名（type="tts"）から emoji_names.tsv を生成する。
+//
+//	go generate ./internal/normalize
+//	go run gen_emoji_data.go -src emoji-data.txt -names en.xml -names derived-en.xml   # ダウンロード済みのファイルから
+//	go run gen_emoji_data.go -src emoji-data.txt -names emoji-test.txt   # emoji-test.txt に載っている CLDR 短縮名から
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const version = "15.0.0"
+
+// CLDR の版（emoji_names.tsv の名前）
+const cldrVersion = "44"
+
+const cldrBase = "https://raw.githubusercontent.com/unicode-org/cldr/release-" + cldrVersion + "/common/"
+
+// 抜き出すプロパティ（出力順）
+var props = []string{"Emoji_Presentation", "Extended_Pictographic", "Emoji_Modifier"}
+
+// 肌色修飾子（名前表には「基本の名前: 肌色」で組み立てられないものだけ載せる）
+var skinTones = []rune{0x1F3FB, 0x1F3FC, 0x1F3FD, 0x1F3FE, 0x1F3FF}
+
+type runeRange struct{ lo, hi rune }
+
+type sources []string
+
+func (s *sources) String() string     { return strings.Join(*s, ",") }
+func (s *sources) Set(v string) error { *s = append(*s, v); return nil }
+
+func main() {
+	src := flag.String("src", "https://unicode.org/Public/"+version+"/ucd/emoji/emoji-data.txt", "emoji-data.txt の URL またはパス")
+	out := flag.String("o", "emoji_data.txt", "出力先")
+	var names sources
+	flag.Var(&names, "names", "CLDR annotations の XML または emoji-test.txt の URL かパス（複数可。既定は CLDR "+cldrVersion+" の en.xml と annotationsDerived/en.xml）")
+	namesOut := flag.String("names-o", "emoji_names.tsv", "名前表の出力先")
+	flag.Parse()
+	if len(names) == 0 {
+		names = sources{cldrBase + "annotations/en.xml", cldrBase + "annotationsDerived/en.xml"}
+	}
+
+	r, err := open(*src)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer r.Close()
+
+	ranges := map[string][]runeRange{}
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		line, _, _ := strings.Cut(sc.Text(), "#")
+		cps, prop, ok := strings.Cut(line, ";")
+		if !ok {
+			continue
+		}
+		prop = strings.TrimSpace(prop)
+		lo, hi, isRange := strings.Cut(strings.TrimSpace(cps), "..")
+		if !isRange {
+			hi = lo
+		}
+		ranges[prop] = append(ranges[prop], runeRange{hexRune(lo), hexRune(hi)})
+	}
+	if err := sc.Err(); err != nil {
+		log.Fatal(err)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# 絵文字判定用の Unicode プロパティ表（Unicode %s emoji-data.txt から gen_emoji_data.go で生成）\n", version)
+	b.WriteString("# 書式: 開始..終了 ; プロパティ\n")
+	b.WriteString("#   Emoji_Presentation   … 既定で絵文字表示される文字（U+FE0E が付けば文字表示）\n")
+	b.WriteString("#   Extended_Pictographic … 絵文字になりうる文字（U+FE0F やZWJ連結で絵文字表示）\n")
+	b.WriteString("#   Emoji_Modifier       … 肌色修飾子\n")
+	for _, p := range props {
+		rs := merge(ranges[p])
+		if len(rs) == 0 {
+			log.Fatalf("%s: no ranges in %s", p, *src)
+		}
+		fmt.Fprintf(&b, "\n# ---- %s ----\n", p)
+		for _, rr := range rs {
+			cp := fmt.Sprintf("%04X", rr.lo)
+			if rr.hi != rr.lo {
+				cp += fmt.Sprintf("..%04X", rr.hi)
+			}
+			fmt.Fprintf(&b, "%-14s; %s\n", cp, p)
+		}
+	}
+	if err := os.WriteFile(*out, []byte(b.String()), 0o644); err != nil {
+		log.Fatal(err)
+	}
+
+	if err := writeNames(names, *namesOut); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// writeNames は絵文字の短縮名を集めて emoji_names.tsv を書く。
+// キーは U+FE0F を除いたコードポイント列。後に読んだ名前で上書きする。
+func writeNames(srcs []string, out string) error {
+	names := map[string]string{}
+	var keys []string // 出現順
+	var from []string
+	for _, src := range srcs {
+		r, err := open(src)
+		if err != nil {
+			return err
+		}
+		data, err := io.ReadAll(r)
+		r.Close()
+		if err != nil {
+			return fmt.Errorf("%s: %w", src, err)
+		}
+		add := func(cluster, name string) {
+			key := strings.ReplaceAll(cluster, "\uFE0F", "")
+			if key == "" || name == "" {
+				return
+			}
+			if _, ok := names[key]; !ok {
+				keys = append(keys, key)
+			}
+			names[key] = name
+		}
+		var desc string
+		if bytes.Contains(data, []byte("<ldml")) {
+			desc, err = readAnnotations(data, add)
+		} else {
+			desc, err = readEmojiTest(data, add)
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", src, err)
+		}
+		if !slices.Contains(from, desc) {
+			from = append(from, desc)
+		}
+	}
+	if len(names) == 0 {
+		return fmt.Errorf("no emoji names in %s", strings.Join(srcs, ", "))
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# 絵文字 → CLDR 短縮名（英語）。emoji_mode: name で使用。\n")
+	fmt.Fprintf(&b, "# CLDR %s の短縮名（%s）から gen_emoji_data.go で生成。\n", cldrVersion, strings.Join(from, "・"))
+	b.WriteString("# 書式: コードポイント（16進、空白区切り。U+FE0F は書かない）<TAB>短縮名\n")
+	b.WriteString("# 肌色付きで「基本の名前: 肌色」と同じ名前になるものは載せない（EmojiName が組み立てる）。\n")
+	n := 0
+	for _, key := range keys {
+		if name := names[key]; name == toneName(key, names) {
+			continue
+		}
+		cps := make([]string, 0, 4)
+		for _, r := range key {
+			cps = append(cps, fmt.Sprintf("%04X", r))
+		}
+		fmt.Fprintf(&b, "%s\t%s\n", strings.Join(cps, " "), names[key])
+		n++
+	}
+	log.Printf("%s: %d names", out, n)
+	return os.WriteFile(out, []byte(b.String()), 0o644)
+}
+
+// toneName は肌色付きの key について「基本の名前: 肌色, 肌色」を組み立てる（肌色なし・基本の名前なしは空）
+func toneName(key string, names map[string]string) string {
+	var tones []string
+	base := strings.Map(func(r rune) rune {
+		if slices.Contains(skinTones, r) {
+			tones = append(tones, names[string(r)])
+			return -1
+		}
+		return r
+	}, key)
+	if len(tones) == 0 || base == "" || names[base] == "" {
+		return ""
+	}
+	return names[base] + ": " + strings.Join(tones, ", ")
+}
+
+// readAnnotations は CLDR annotations の XML から短縮名（type="tts"）を読む
+func readAnnotations(data []byte, add func(cluster, name string)) (string, error) {
+	var doc struct {
+		Annotations []struct {
+			CP   string `xml:"cp,attr"`
+			Type string `xml:"type,attr"`
+			Text string `xml:",chardata"`
+		} `xml:"annotations>annotation"`
+	}
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		return "", err
+	}
+	for _, a := range doc.Annotations {
+		if a.Type == "tts" {
+			add(a.CP, strings.TrimSpace(a.Text))
+		}
+	}
+	return "annotations", nil
+}
+
+// readEmojiTest は emoji-test.txt の「# 😀 E1.0 grinning face」から短縮名を読む
+func readEmojiTest(data []byte, add func(cluster, name string)) (string, error) {
+	desc := "emoji-test.txt"
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for sc.Scan() {
+		line := sc.Text()
+		if v, ok := strings.CutPrefix(line, "# Version: "); ok {
+			desc = "emoji-test.txt " + strings.TrimSpace(v) + " 収録分"
+			continue
+		}
+		cps, comment, ok := strings.Cut(line, "#")
+		if !ok || strings.TrimSpace(cps) == "" {
+			continue
+		}
+		cps, _, _ = strings.Cut(cps, ";")
+		var cluster strings.Builder
+		for _, cp := range strings.Fields(cps) {
+			cluster.WriteRune(hexRune(cp))
+		}
+		// 絵文字, 版（E1.0）, 名前
+		f := strings.SplitN(strings.TrimSpace(comment), " ", 3)
+		if len(f) == 3 && strings.HasPrefix(f[1], "E") {
+			add(cluster.String(), f[2])
+		}
+	}
+	return desc, sc.Err()
+}
+
+func open(src string) (io.ReadCloser, error) {
+	if !strings.HasPrefix(src, "http://") && !strings.HasPrefix(src, "https://") {
+		return os.Open(src)
+	}
+	resp, err := http.Get(src)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("%s: %s", src, resp.Status)
+	}
+	return resp.Body, nil
+}
+
+// merge は範囲を並べ、重なりや隣接をまとめる
+func merge(rs []runeRange) []runeRange {
+	sort.Slice(rs, func(i, j int) bool { return rs[i].lo < rs[j].lo })
+	var out []runeRange
+	for _, r := range rs {
+		if n := len(out); n > 0 && r.lo <= out[n-1].hi+1 {
+			out[n-1].hi = max(out[n-1].hi, r.hi)
+			continue
+		}
+		out = append(out, r)
+	}
+	return out
+}
+
+func hexRune(s string) rune {
+	n, err := strconv.ParseUint(strings.TrimSpace(s), 16, 32)
+	if err != nil {
+		log.Fatalf("bad code point %q", s)
+	}
+	return rune(n)
+}
@@ -0,0 +1,66 @@
+package normalize
+
+import (
+	"bytes"
+	stdhtml "html"
+	"io"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// DecodeHTMLEntities は &amp; &nbsp; &#12354; &#x3042; などの文字参照を復号する
+func DecodeHTMLEntities(s string) string {
+	if !strings.Contains(s, "&") {
+		return s
+	}
+	return stdhtml.UnescapeString(s)
+}
+
+// StripHTML は HTML トークナイザでタグ・コメント・doctype・<script>/<style> の中身を取り除く。
+// CDATA は中身だけを残す。タグにならない「a < b > c」のような地の文の < > はそのまま残し、
+// 文字参照も復号しない（decode_html_entities に任せる）。
+func StripHTML(s string) string {
+	if !strings.Contains(s, "<") {
+		return s
+	}
+	z := html.NewTokenizer(strings.NewReader(s))
+	z.AllowCDATA(true)
+
+	var b strings.Builder
+	b.Grow(len(s))
+	skip := "" // 中身ごと捨てる要素（script/style）の中なら要素名
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			if z.Err() == io.EOF {
+				return b.String()
+			}
+			// 読めなかった残りはそのまま（通常は EOF のみ）
+			b.Write(z.Raw())
+			return b.String()
+		case html.TextToken:
+			if skip != "" {
+				continue
+			}
+			raw := z.Raw()
+			if bytes.HasPrefix(raw, []byte("<![CDATA[")) {
+				b.Write(z.Text())
+				continue
+			}
+			b.Write(raw)
+		case html.StartTagToken:
+			name, _ := z.TagName()
+			if tag := string(name); tag == "script" || tag == "style" {
+				skip = tag
+			}
+		case html.EndTagToken:
+			name, _ := z.TagName()
+			if string(name) == skip {
+				skip = ""
+			}
+		default:
+			// SelfClosingTag / Comment / Doctype は捨てる
+		}
+	}
+}
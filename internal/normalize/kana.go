@@ -0,0 +1,110 @@
+package normalize
+
+import (
+	"strings"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// 小書き仮名 → 通常の仮名
+var smallKana = map[rune]rune{
+	'ぁ': 'あ', 'ぃ': 'い', 'ぅ': 'う', 'ぇ': 'え', 'ぉ': 'お',
+	'っ': 'つ', 'ゃ': 'や', 'ゅ': 'ゆ', 'ょ': 'よ', 'ゎ': 'わ', 'ゕ': 'か', 'ゖ': 'け',
+	'ァ': 'ア', 'ィ': 'イ', 'ゥ': 'ウ', 'ェ': 'エ', 'ォ': 'オ',
+	'ッ': 'ツ', 'ャ': 'ヤ', 'ュ': 'ユ', 'ョ': 'ヨ', 'ヮ': 'ワ', 'ヵ': 'カ', 'ヶ': 'ケ',
+	'ㇰ': 'ク', 'ㇱ': 'シ', 'ㇲ': 'ス', 'ㇳ': 'ト', 'ㇴ': 'ヌ', 'ㇵ': 'ハ', 'ㇶ': 'ヒ', 'ㇷ': 'フ',
+	'ㇸ': 'ヘ', 'ㇹ': 'ホ', 'ㇺ': 'ム', 'ㇻ': 'ラ', 'ㇼ': 'リ', 'ㇽ': 'ル', 'ㇾ': 'レ', 'ㇿ': 'ロ',
+	'ｧ': 'ｱ', 'ｨ': 'ｲ', 'ｩ': 'ｳ', 'ｪ': 'ｴ', 'ｫ': 'ｵ', 'ｬ': 'ﾔ', 'ｭ': 'ﾕ', 'ｮ': 'ﾖ', 'ｯ': 'ﾂ',
+}
+
+// widenHalfKana は半角カタカナ（ｦ〜ﾟ）を全角にする（ｶﾞ → ガ のように濁点は合成する）。
+// 既定で有効な full_digit_to_half（width.Narrow）はカタカナも半角にするため、
+// かなの工程は全角に戻してから処理し、全角・半角どちらの入力も同じ結果にする。
+func widenHalfKana(s string) string {
+	if !strings.ContainsFunc(s, isHalfKana) {
+		return s
+	}
+	var b strings.Builder
+	b.Grow(len(s) * 2)
+	rs := []rune(s)
+	for i := 0; i < len(rs); {
+		if !isHalfKana(rs[i]) {
+			b.WriteRune(rs[i])
+			i++
+			continue
+		}
+		j := i
+		for j < len(rs) && isHalfKana(rs[j]) {
+			j++
+		}
+		b.WriteString(norm.NFKC.String(string(rs[i:j])))
+		i = j
+	}
+	return b.String()
+}
+
+func isHalfKana(r rune) bool { return r >= 'ｦ' && r <= 'ﾟ' }
+
+// HiraganaToKatakana はひらがな（ゝゞ を含む）をカタカナに変換する。半角カタカナは全角にする。
+func HiraganaToKatakana(s string) string {
+	s = widenHalfKana(s)
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'ぁ' && r <= 'ゖ', r == 'ゝ' || r == 'ゞ':
+			return r + 0x60
+		default:
+			return r
+		}
+	}, s)
+}
+
+// KatakanaToHiragana はカタカナ（ヽヾ を含む）をひらがなに変換する。
+// ひらがなに対応のない ヷヸヹヺ・ㇰ 等はそのまま。半角カタカナも全角にしてから変換する。
+func KatakanaToHiragana(s string) string {
+	s = widenHalfKana(s)
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'ァ' && r <= 'ヶ', r == 'ヽ' || r == 'ヾ':
+			return r - 0x60
+		default:
+			return r
+		}
+	}, s)
+}
+
+// FoldSmallKana は小書き仮名（ぁ ッ ㇰ ｧ 等）を通常の仮名にする。半角カタカナは全角にする。
+func FoldSmallKana(s string) string {
+	s = widenHalfKana(s)
+	return strings.Map(func(r rune) rune {
+		if l, ok := smallKana[r]; ok {
+			return l
+		}
+		return r
+	}, s)
+}
+
+// StripDakuten は濁点・半濁点を取り除く（ガ→カ, パ→ハ, ヴ→ウ）。
+// 単独の濁点記号（゛゜ﾞﾟ と結合用 U+3099/U+309A）も削除する。半角カタカナは全角にする。
+func StripDakuten(s string) string {
+	s = widenHalfKana(s)
+	var b strings.Builder
+	b.Grow(len(s))
+	for _, r := range s {
+		switch r {
+		case '\u3099', '\u309A', '゛', '゜', 'ﾞ', 'ﾟ':
+			continue
+		}
+		if isKana(r) {
+			d := []rune(norm.NFD.String(string(r)))
+			if len(d) == 2 && (d[1] == '\u3099' || d[1] == '\u309A') {
+				r = d[0]
+			}
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func isKana(r rune) bool {
+	return (r >= 'ぁ' && r <= 'ゟ') || (r >= '゠' && r <= 'ヿ')
+}
@@ -0,0 +1,121 @@
+package normalize
+
+import (
+	_ "embed"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// 既定の異体字テーブル（1列目=異体字, 2列目=代表字）
+//
+//go:embed kanji_variants.tsv
+var builtinKanjiVariants string
+
+var (
+	kanjiOnce     sync.Once
+	kanjiVariants map[rune]rune
+	kanjiErr      error
+)
+
+// KanjiVariants は既定の異体字テーブルを返す（呼び出し側で変更しないこと）
+func KanjiVariants() map[rune]rune {
+	kanjiOnce.Do(func() {
+		kanjiVariants = map[rune]rune{}
+		kanjiErr = readKanjiVariants(strings.NewReader(builtinKanjiVariants), "builtin", kanjiVariants)
+	})
+	if kanjiErr != nil {
+		panic("normalize: " + kanjiErr.Error())
+	}
+	return kanjiVariants
+}
+
+// loadKanjiVariants は既定テーブルに path の内容を重ねたテーブルを返す（path が空なら既定のまま）。
+// 異体字と代表字を同じ字にすると、その字の畳み込みを無効にできる。
+func loadKanjiVariants(path string) (map[rune]rune, error) {
+	base := KanjiVariants()
+	if path == "" {
+		return base, nil
+	}
+	m := make(map[rune]rune, len(base))
+	for k, v := range base {
+		m[k] = v
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	if err := readKanjiVariants(f, path, m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func readKanjiVariants(r io.Reader, name string, m map[rune]rune) error {
+	cr := csv.NewReader(r)
+	cr.Comma = '\t'
+	cr.Comment = '#'
+	cr.FieldsPerRecord = -1
+	cr.LazyQuotes = true
+	for line := 1; ; line++ {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("kanji variants %s: %w", name, err)
+		}
+		if len(rec) < 2 {
+			return fmt.Errorf("kanji variants %s: line %d: want 2 columns (variant, canonical)", name, line)
+		}
+		from := strings.TrimPrefix(strings.TrimSpace(rec[0]), "\ufeff")
+		to := strings.TrimSpace(rec[1])
+		if utf8.RuneCountInString(from) != 1 || utf8.RuneCountInString(to) != 1 {
+			return fmt.Errorf("kanji variants %s: line %d: each column must be a single character", name, line)
+		}
+		f, _ := utf8.DecodeRuneInString(from)
+		t, _ := utf8.DecodeRuneInString(to)
+		if f == t {
+			delete(m, f)
+			continue
+		}
+		m[f] = t
+	}
+}
+
+// isIVS は漢字の異体字セレクタ（U+E0100–U+E01EF）か
+func isIVS(r rune) bool {
+	return r >= 0xE0100 && r <= 0xE01EF
+}
+
+// RemoveIVS は異体字セレクタを削除する（Mn のため remove_non_printable では消えない）
+func RemoveIVS(s string) string {
+	return removeByPredicate(s, isIVS)
+}
+
+// FoldKanjiVariants は異体字セレクタを除いたうえで、異体字を代表字に置き換える
+func FoldKanjiVariants(s string, table map[rune]rune) string {
+	return strings.Map(func(r rune) rune {
+		if isIVS(r) {
+			return -1
+		}
+		if t, ok := table[r]; ok {
+			return t
+		}
+		return r
+	}, s)
+}
+
+func applyKanjiVariants(s string, o *Options) string {
+	table := o.kanjiVariants
+	if table == nil {
+		// Prepare 未実行時は既定テーブル
+		table = KanjiVariants()
+	}
+	return FoldKanjiVariants(s, table)
+}
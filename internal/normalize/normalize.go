@@ -1,27 +1,33 @@
 package normalize
 
 import (
+	"fmt"
 	"regexp"
+	"strconv"
 	"strings"
 	"unicode"
 
 	"golang.org/x/text/transform"
-	"golang.org/x/text/unicode/norm"
-	"golang.org/x/text/width"
 )
 
 type Options struct {
+	UnicodeForm   string // nfc|nfd|nfkc|nfkd|none（空は nfkc）
+	PreserveChars string // Unicode 正規化の対象外にする文字（㈱ ① ™ など）
+
 	ToUpper            bool
 	ToLower            bool
 	HalfKanaToFull     bool
 	FullKanaToHalf     bool
 	FullDigitToHalf    bool
 	ParenNumToHalf     bool
+	NormalizeNumbers   bool // 漢数字・丸数字・桁区切り・全角小数点 → 半角の算用数字
 	DashToHyphen       bool
 	RemoveParens       bool
 	RemoveNonPrintable bool
 	RemoveCRLFOnly     bool
 	RemoveHTML         bool
+	HTMLMode           string // remove_html の方式: regex(既定) | tokenizer
+	DecodeHTMLEntities bool   // &amp; &nbsp; &#12354; などを復号
 	RemoveChars        string
 	RemoveCharsList    []string
 
@@ -32,10 +38,66 @@ type Options struct {
 	RemovePunctuation bool
 	RemoveSymbols     bool
 	RemoveEmoji       bool
+	EmojiMode         string // remove(既定) | replace | name
+	EmojiPlaceholder  string // replace（name で名前がない場合も）の置換文字列。空なら "[emoji]"
+
+	HiraganaToKatakana bool // ひらがな → カタカナ
+	KatakanaToHiragana bool // カタカナ → ひらがな
+	FoldSmallKana      bool // ァ → ア, っ → つ
+	StripDakuten       bool // ガ → カ, パ → ハ
+
+	RomajiToKana    bool   // Yamada Taro → ヤマダ タロ（ローマ字として読める語だけ）
+	KanaToRomaji    bool   // ヤマダ タロウ → yamada taro
+	RomajiSystem    string // kana_to_romaji の綴り: hepburn(既定) | kunrei
+	RomajiLongVowel string // kana_to_romaji の長音: omit(既定: taro) | macron(tarō) | spelled(tarou)
+
+	FoldKanjiVariants bool          // 髙 → 高, 﨑 → 崎（異体字セレクタも除去）
+	KanjiVariantTable string        // 既定テーブルに重ねる TSV（空なら既定のみ）
+	RemoveIVS         bool          // 異体字セレクタ（U+E0100–U+E01EF）のみ除去
+	kanjiVariants     map[rune]rune // Prepare で読み込み済み
+
+	StripDiacritics   bool                  // Müller → Muller, ß → ss（ラテン・ギリシャ・キリル文字のみ）
+	DiacriticsScripts []string              // strip_diacritics の対象: latin | greek | cyrillic（空ならすべて）
+	diacriticScripts  []*unicode.RangeTable // Prepare で解決済み
+
+	Dictionaries []DictionaryFile   // 表記ゆれ辞書（記述順に適用）
+	dictionaries []loadedDictionary // Prepare で読み込み済み
+
+	SpaceToASCII    bool   // Zs（全角空白・NBSP 等）→ 半角空白
+	CollapseSpaces  bool   // 連続する空白・タブを半角空白 1 つに
+	TrimChars       string // 前後から取り除く文字（集合）
+	TrimHyphens     bool   // 前後のハイフン類を取り除く
+	TrimUnderscores bool   // 前後のアンダースコアを取り除く
+
+	Replace    []ReplaceRule    // 正規表現置換（記述順に適用）
+	replaceRes []*regexp.Regexp // 事前コンパイル済み
+
+	Type       string // 列の型（date）。空なら文字列のまま
+	OnError    string // 型として解釈できない値: keep(既定) | blank | report
+	DateLayout string // type: date の出力書式（Go の time レイアウト。既定 2006-01-02）
+
+	AddressPrefecture string            // type: address の都道府県: keep(既定) | add | strip
+	AddressCityTable  string            // 既定の市区町村テーブルに重ねる TSV（空なら既定のみ）
+	addressCities     map[string]string // Prepare で読み込み済み
+
+	CompanyLegalForm string // type: company_name の法人格: canonical(既定) | strip
+
+	PhoneFormat      string // type: phone の出力: digits(既定) | hyphen | e164
+	PostalCodeFormat string // type: postal_code の出力: digits(既定) | hyphen
+	EmailFoldGmail   bool   // type: email で Gmail の "." と "+タグ" を畳み込む
+
+	URLDropParams         []string // type: url で取り除くクエリパラメータ名（utm_* などのパターン）
+	URLSortQuery          bool     // type: url でクエリをパラメータ名順に並べる
+	URLStripFragment      bool     // type: url で #以降を取り除く
+	URLStripWWW           bool     // type: url でホスト先頭の www. を取り除く
+	URLStripTrailingSlash bool     // type: url でパス末尾の / を取り除く
+
+	Steps    []string // 実行する工程名と順序（空なら既定順で有効な工程のみ）
+	pipeline []Step   // Prepare で解決済みの工程列
 }
 
 var (
-	reParenNum     = regexp.MustCompile(`［?([０-９])］?|\(([０-９])\)|【([０-９])】|〔([０-９])〕|[①-⑨]`)
+	reParenNum     = regexp.MustCompile(`［?([０-９])］?|\(([０-９])\)|【([０-９])】|〔([０-９])〕|[①-⑳㉑-㉟㊱-㊿]`)
 	reDash         = regexp.MustCompile(`[ー－―–—‐]`)
 	reParens       = regexp.MustCompile(`[()\[\]{}「」『』【】［］〔〕（）]`)
 	reNonPrintable = regexp.MustCompile(`[\p{Cc}\p{Cf}]`)
@@ -63,123 +125,139 @@ func compileTagRegex(tags []string) *regexp.Regexp {
 	return regexp.MustCompile(pat)
 }
 
-func (o *Options) Prepare() {
-	if len(o.RemoveHTMLTags) > 0 {
-		o.removeTagsRe = compileTagRegex(o.RemoveHTMLTags)
-	} else {
-		o.removeTagsRe = nil
+// Validate は列挙値・パターン・steps を検証する（ファイルは読まない）。
+// 設定読込時の検証と Prepare の両方がこれを使う。
+func (o *Options) Validate() error {
+	if err := ValidateUnicodeForm(o.UnicodeForm); err != nil {
+		return err
 	}
-}
-
-func Clean(s string, opt Options) string {
-	// 0. NFKC
-	s = norm.NFKC.String(s)
-
-	// 1. 幅変換
-	if opt.HalfKanaToFull {
-		s = transformString(width.Widen, s)
-	}
-	if opt.FullKanaToHalf {
-		s = transformString(width.Narrow, s)
+	if err := ValidateType(o.Type, o.OnError); err != nil {
+		return err
 	}
-	if opt.FullDigitToHalf {
-		s = transformString(width.Narrow, s)
+	for _, m := range []struct {
+		key, value string
+		allowed    []string
+	}{
+		{"address_prefecture", o.AddressPrefecture, []string{"keep", "add", "strip"}},
+		{"company_legal_form", o.CompanyLegalForm, []string{"canonical", "strip"}},
+		{"phone_format", o.PhoneFormat, []string{"digits", "hyphen", "e164"}},
+		{"postal_code_format", o.PostalCodeFormat, []string{"digits", "hyphen"}},
+		{"romaji_system", o.RomajiSystem, []string{"hepburn", "kunrei"}},
+		{"romaji_long_vowel", o.RomajiLongVowel, []string{"omit", "macron", "spelled"}},
+		{"html_mode", o.HTMLMode, []string{"regex", "tokenizer"}},
+		{"emoji_mode", o.EmojiMode, []string{"remove", "replace", "name"}},
+	} {
+		if err := oneOf(m.key, m.value, m.allowed...); err != nil {
+			return err
+		}
 	}
-	if opt.ParenNumToHalf {
-		s = reParenNum.ReplaceAllStringFunc(s, fullNumToHalf)
+	if err := validateURLRules(o.urlRules()); err != nil {
+		return err
 	}
-
-	// 2. ハイフン
-	if opt.DashToHyphen {
-		s = reDash.ReplaceAllString(s, "-")
+	if _, err := resolveDiacriticScripts(o.DiacriticsScripts); err != nil {
+		return err
 	}
-
-	// 3. ケース
-	if opt.ToUpper {
-		s = strings.ToUpper(s)
-	} else if opt.ToLower {
-		s = strings.ToLower(s)
+	for i, r := range o.Replace {
+		if _, err := r.Compile(); err != nil {
+			return fmt.Errorf("replace[%d]: %w", i, err)
+		}
 	}
+	_, err := resolveSteps(o.Steps)
+	return err
+}
 
-	// 4. カッコ
-	if opt.RemoveParens {
-		s = reParens.ReplaceAllString(s, "")
+// Prepare は検証のうえ、正規表現の事前コンパイル・辞書類の読込・steps の解決を行う。
+func (o *Options) Prepare() error {
+	if err := o.Validate(); err != nil {
+		return err
 	}
-
-	// 5-1. 特定タグだけ除去（中身は保持）
-	if opt.removeTagsRe != nil {
-		s = opt.removeTagsRe.ReplaceAllString(s, "")
+	scripts, err := resolveDiacriticScripts(o.DiacriticsScripts)
+	if err != nil {
+		return err
 	}
+	o.diacriticScripts = scripts
 
-	// 5-2. HTML 全除去（remove_html: true のとき）
-	if opt.RemoveHTML {
-		s = reHTMLTag.ReplaceAllString(s, "")
+	if len(o.RemoveHTMLTags) > 0 {
+		o.removeTagsRe = compileTagRegex(o.RemoveHTMLTags)
+	} else {
+		o.removeTagsRe = nil
 	}
 
-	// 6. 非印刷
-	if opt.RemoveNonPrintable {
-		s = reNonPrintable.ReplaceAllString(s, "")
+	res, err := compileReplaceRules(o.Replace)
+	if err != nil {
+		return err
 	}
+	o.replaceRes = res
 
-	// ★ カテゴリ系の削除
-	if opt.RemovePunctuation {
-		s = removeByPredicate(s, func(r rune) bool { return unicode.In(r, unicode.Punct) })
-	}
-	if opt.RemoveSymbols {
-		s = removeByPredicate(s, func(r rune) bool { return unicode.In(r, unicode.Symbol) })
-	}
-	if opt.RemoveEmoji {
-		s = removeByPredicate(s, isEmoji)
+	dicts, err := loadDictionaries(o.Dictionaries)
+	if err != nil {
+		return err
 	}
+	o.dictionaries = dicts
 
-	// 6a. 改行だけ削除（CR/LF のみ）
-	if opt.RemoveCRLFOnly {
-		s = strings.Map(func(r rune) rune {
-			if r == '\r' || r == '\n' {
-				return -1
-			}
-			return r
-		}, s)
+	o.kanjiVariants = nil
+	if o.FoldKanjiVariants || o.KanjiVariantTable != "" {
+		table, err := loadKanjiVariants(o.KanjiVariantTable)
+		if err != nil {
+			return err
+		}
+		o.kanjiVariants = table
 	}
 
-	// 6b. 非印刷（制御/書式）を一括削除
-	if opt.RemoveNonPrintable {
-		s = reNonPrintable.ReplaceAllString(s, "")
+	o.addressCities = nil
+	if strings.EqualFold(o.Type, "address") || o.AddressCityTable != "" {
+		table, err := loadAddressCities(o.AddressCityTable)
+		if err != nil {
+			return err
+		}
+		o.addressCities = table
 	}
 
-	// 個別文字削除（配列も併用）
-	if opt.RemoveChars != "" || len(opt.RemoveCharsList) > 0 {
-		var b strings.Builder
-		for _, s2 := range opt.RemoveCharsList {
-			b.WriteString(s2)
+	o.pipeline = nil
+	if len(o.Steps) > 0 {
+		steps, err := resolveSteps(o.Steps)
+		if err != nil {
+			return err
 		}
-		b.WriteString(opt.RemoveChars)
-		s = removeChars(s, b.String())
+		o.pipeline = steps
 	}
+	return nil
+}
 
-	// 7. 個別削除
-	if opt.RemoveChars != "" {
-		s = removeChars(s, opt.RemoveChars)
+// oneOf は列挙型の設定値を検証する（空は既定値として許可）
+func oneOf(key, value string, allowed ...string) error {
+	if value == "" {
+		return nil
+	}
+	for _, a := range allowed {
+		if strings.EqualFold(value, a) {
+			return nil
+		}
 	}
+	return fmt.Errorf("unknown %s: %q (use %s)", key, value, strings.Join(allowed, ", "))
+}
 
-	// 8. 特定部分文字列のリテラル除去
-	if len(opt.RemoveSubstrings) > 0 {
-		for _, sub := range opt.RemoveSubstrings {
-			if sub == "" {
-				continue
+// Clean は steps 指定があればその順に、なければ既定順で有効な工程を実行する。
+func Clean(s string, opt Options) string {
+	if opt.pipeline == nil && len(opt.Steps) > 0 {
+		// Prepare 未実行でも steps を尊重する（未知の名前は無視）
+		for _, n := range opt.Steps {
+			if st, ok := LookupStep(n); ok {
+				opt.pipeline = append(opt.pipeline, st)
 			}
-			s = strings.ReplaceAll(s, sub, "")
 		}
 	}
 
-	// 9. 個別文字（集合）削除（配列＋文字列を合算）
-	if opt.RemoveChars != "" || len(opt.RemoveCharsList) > 0 {
-		var b strings.Builder
-		for _, set := range opt.RemoveCharsList {
-			b.WriteString(set)
+	if opt.pipeline != nil {
+		for _, st := range opt.pipeline {
+			s = st.apply(s, &opt)
+		}
+	} else {
+		for _, st := range defaultOrder {
+			if st.enabled(&opt) {
+				s = st.apply(s, &opt)
+			}
 		}
-		b.WriteString(opt.RemoveChars)
-		s = removeChars(s, b.String())
 	}
 
 	return strings.TrimSpace(s)
@@ -197,16 +275,18 @@ func transformString(t transform.Transformer, s string) string {
 }
 
 func fullNumToHalf(m string) string {
-	return strings.Map(func(r rune) rune {
-		switch {
+	var b strings.Builder
+	for _, r := range m {
+		switch n, circled := CircledNumber(r); {
 		case IsZenkakuDigit(r):
-			return r - '０' + '0'
-		case r >= '①' && r <= '⑨':
-			return r - '①' + '1'
+			b.WriteRune(r - '０' + '0')
+		case circled:
+			b.WriteString(strconv.Itoa(n))
 		default:
-			return r
+			b.WriteRune(r)
 		}
-	}, m)
+	}
+	return b.String()
 }
 
 func removeChars(s, chars string) string {
@@ -234,30 +314,3 @@ func removeByPredicate(s string, pred func(rune) bool) string {
 		return r
 	}, s)
 }
-
-func isEmoji(r rune) bool {
-	switch {
-	case r == 0x200D || r == 0xFE0F || r == 0x20E3: // ZWJ / VS-16 / keycap
-		return true
-	case r >= 0x1F3FB && r <= 0x1F3FF: // skin tones
-		return true
-	case r >= 0x1F600 && r <= 0x1F64F: // Emoticons
-		return true
-	case r >= 0x1F300 && r <= 0x1F5FF: // Misc Symbols and Pictographs
-		return true
-	case r >= 0x1F680 && r <= 0x1F6FF: // Transport & Map
-		return true
-	case r >= 0x2600 && r <= 0x26FF: // Misc Symbols
-		return true
-	case r >= 0x2700 && r <= 0x27BF: // Dingbats
-		return true
-	case r >= 0x1F900 && r <= 0x1F9FF: // Supplemental Symbols & Pictographs
-		return true
-	case r >= 0x1FA70 && r <= 0x1FAFF: // Symbols & Pictographs Extended-A
-		return true
-	case r >= 0x1F1E6 && r <= 0x1F1FF: // Regional Indicator
-		return true
-	default:
-		return false
-	}
-}
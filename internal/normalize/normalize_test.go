@@ -1,6 +1,11 @@
 package normalize
 
-import "testing"
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
 
 func TestClean_Full(t *testing.T) {
 	opts := Options{
@@ -16,3 +21,633 @@ func TestClean_Full(t *testing.T) {
 		t.Fatalf("want %q got %q", want, got)
 	}
 }
+
+func TestClean_Steps(t *testing.T) {
+	// HTML を NFKC より先に除去すると全角の山括弧はタグ扱いされない
+	opts := Options{
+		RemoveHTML: true,
+		Steps:      []string{"remove_html", "unicode_normalize", "to_lower"},
+	}
+	if err := opts.Prepare(); err != nil {
+		t.Fatal(err)
+	}
+	got := Clean("<b>Ａ</b>＜Ｂ＞", opts)
+	want := "a<b>"
+	if got != want {
+		t.Fatalf("want %q got %q", want, got)
+	}
+
+	// 既定順では NFKC 後に HTML 除去されるため ＜Ｂ＞ も消える
+	opts = Options{ToLower: true, RemoveHTML: true}
+	if err := opts.Prepare(); err != nil {
+		t.Fatal(err)
+	}
+	if got := Clean("<b>Ａ</b>＜Ｂ＞", opts); got != "a" {
+		t.Fatalf("default order: want %q got %q", "a", got)
+	}
+}
+
+func TestPrepare_UnknownStep(t *testing.T) {
+	opts := Options{Steps: []string{"unicode_normalize", "no_such_step"}}
+	if err := opts.Prepare(); err == nil {
+		t.Fatal("want error for unknown step")
+	}
+}
+
+func TestStep_Apply(t *testing.T) {
+	st, ok := LookupStep("remove_chars")
+	if !ok {
+		t.Fatal("remove_chars not registered")
+	}
+	got := st.Apply("a-b.c", &Options{RemoveCharsList: []string{"-", "."}})
+	if got != "abc" {
+		t.Fatalf("want %q got %q", "abc", got)
+	}
+}
+
+func TestClean_Replace(t *testing.T) {
+	opts := Options{
+		Replace: []ReplaceRule{
+			{Pattern: `vol\.?\s*(\d+)`, Replacement: "v$1", Flags: "i"},
+			{Pattern: `(?P<y>\d{4})年`, Replacement: "${y}"},
+		},
+	}
+	if err := opts.Prepare(); err != nil {
+		t.Fatal(err)
+	}
+	got := Clean("VOL. 12 2024年", opts)
+	want := "v12 2024"
+	if got != want {
+		t.Fatalf("want %q got %q", want, got)
+	}
+
+	bad := Options{Replace: []ReplaceRule{{Pattern: `(`}}}
+	if err := bad.Prepare(); err == nil {
+		t.Fatal("want error for invalid pattern")
+	}
+}
+
+func TestDictionary_Replace(t *testing.T) {
+	d := NewDictionary()
+	d.Add("(株)", "株式会社")
+	d.Add("Univ.", "University")
+	d.Add("Univ", "University")
+	d.Add("U", "You")
+
+	// 最長一致（部分文字列）
+	if got := d.Replace("(株)山田 Univ. of X", false); got != "株式会社山田 University of X" {
+		t.Fatalf("longest: got %q", got)
+	}
+	// 語単位では語の途中の U は置換しない
+	if got := d.Replace("UNIX U Univ", true); got != "UNIX You University" {
+		t.Fatalf("token: got %q", got)
+	}
+}
+
+func TestClean_Dictionary(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "company.tsv")
+	tsv := "# 表記ゆれ\t正規形\n(株)\t株式会社\n(有)\t有限会社\n"
+	if err := os.WriteFile(path, []byte(tsv), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	opts := Options{RemoveParens: true, Dictionaries: []DictionaryFile{{Path: path}}}
+	if err := opts.Prepare(); err != nil {
+		t.Fatal(err)
+	}
+	// NFKC で ㈱ → (株) になった後、カッコ削除より前に辞書を引く
+	got := Clean("㈱山田（東京）", opts)
+	want := "株式会社山田東京"
+	if got != want {
+		t.Fatalf("want %q got %q", want, got)
+	}
+}
+
+func TestClean_KanaFolding(t *testing.T) {
+	opts := Options{HiraganaToKatakana: true}
+	if a, b := Clean("がっこう", opts), Clean("ガッコウ", opts); a != b {
+		t.Fatalf("hiragana_to_katakana: %q != %q", a, b)
+	}
+
+	opts = Options{KatakanaToHiragana: true, FoldSmallKana: true, StripDakuten: true}
+	got := Clean("ヴァイオリン バッハ ﾊﾟﾝ", opts)
+	want := "うあいおりん はつは はん"
+	if got != want {
+		t.Fatalf("want %q got %q", want, got)
+	}
+}
+
+func TestClean_Romaji(t *testing.T) {
+	// 両方の表記が同じキーになる
+	opts := Options{RomajiToKana: true, KanaToRomaji: true}
+	if err := opts.Prepare(); err != nil {
+		t.Fatal(err)
+	}
+	cases := map[string]string{
+		"Yamada Taro":    "yamada taro",
+		"ヤマダ タロウ":        "yamada taro",
+		"やまだ たろう":        "yamada taro",
+		"Tōkyō":          "tokyo",
+		"Toukyou":        "tokyo",
+		"Ohno":           "ono",
+		"オオノ":            "ono",
+		"Shimbun":        "shinbun",
+		"Tsutsumi Syuzi": "tsutsumi shuji",
+		"マッチャ ABC Corp":  "matcha ABC Corp",
+		// ローマ字として読めない英語の語はそのまま（l・rr・ll は日本語の綴りにない）
+		"Hello World":     "Hello World",
+		"Lisa Carroll":    "Lisa Carroll",
+		"Terry Yamada":    "Terry yamada",
+		"Emily Watanabe":  "Emily watanabe",
+		"Jacques Quentin": "Jacques Quentin",
+	}
+	for in, want := range cases {
+		if got := Clean(in, opts); got != want {
+			t.Fatalf("%q: want %q got %q", in, want, got)
+		}
+	}
+
+	// 母音の前の n はナ行（Shinichi は シニチ）。シンイチ と読ませるには n' と書く
+	for in, want := range map[string]string{
+		"Shinichi": "シニチ", "Kenichi": "ケニチ", "Shin'ichi": "シンイチ", "Ken'ichi": "ケンイチ",
+	} {
+		if got := RomajiToKana(in); got != want {
+			t.Fatalf("RomajiToKana(%q): want %q got %q", in, want, got)
+		}
+	}
+
+	for _, c := range []struct{ system, long, want string }{
+		{"kunrei", "macron", "zyunitirō rāmen mattya"},
+		{"hepburn", "spelled", "junichirou raamen matcha"},
+	} {
+		if got := KanaToRomaji("ジュンイチロウ ラーメン マッチャ", c.system, c.long); got != c.want {
+			t.Fatalf("%s/%s: want %q got %q", c.system, c.long, c.want, got)
+		}
+	}
+
+	if err := (&Options{RomajiLongVowel: "oh"}).Prepare(); err == nil {
+		t.Fatal("want error for unknown romaji_long_vowel")
+	}
+}
+
+func TestClean_KanjiVariants(t *testing.T) {
+	opts := Options{FoldKanjiVariants: true}
+	if err := opts.Prepare(); err != nil {
+		t.Fatal(err)
+	}
+	cases := map[string]string{
+		"髙﨑":           "高崎",
+		"渡邊":           "渡辺",
+		"渡邉":           "渡辺",
+		"齋藤":           "斎藤",
+		"斉藤":           "斎藤",
+		"葛\U000E0100城": "葛城", // IVS は除去
+	}
+	for in, want := range cases {
+		if got := Clean(in, opts); got != want {
+			t.Fatalf("%q: want %q got %q", in, want, got)
+		}
+	}
+
+	// 上書きテーブル: 斉 は畳み込まない（同じ字を指定）、﨔 → 欅 を追加
+	path := filepath.Join(t.TempDir(), "variants.tsv")
+	if err := os.WriteFile(path, []byte("斉\t斉\n﨔\t欅\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	opts = Options{FoldKanjiVariants: true, KanjiVariantTable: path}
+	if err := opts.Prepare(); err != nil {
+		t.Fatal(err)
+	}
+	if got := Clean("斉藤﨔髙", opts); got != "斉藤欅高" {
+		t.Fatalf("override: got %q", got)
+	}
+
+	if got := Clean("葛\U000E0100城", Options{RemoveNonPrintable: true, RemoveIVS: true}); got != "葛城" {
+		t.Fatalf("remove_ivs: got %q", got)
+	}
+}
+
+func TestClean_UnicodeForm(t *testing.T) {
+	// 既定（NFKC）では ㈱ ① ™ が分解される
+	if got := Clean("㈱Ａ①™", Options{}); got != "(株)A1TM" {
+		t.Fatalf("nfkc: got %q", got)
+	}
+
+	opts := Options{PreserveChars: "㈱①"}
+	if got := Clean("㈱Ａ①™", opts); got != "㈱A①TM" {
+		t.Fatalf("preserve_chars: got %q", got)
+	}
+
+	opts = Options{UnicodeForm: "nfc"}
+	if got := Clean("㈱Ａ①ガ", opts); got != "㈱Ａ①ガ" {
+		t.Fatalf("nfc: got %q", got)
+	}
+
+	opts = Options{UnicodeForm: "none"}
+	if got := Clean("ｶﾞ", opts); got != "ｶﾞ" {
+		t.Fatalf("none: got %q", got)
+	}
+
+	if err := (&Options{UnicodeForm: "nfx"}).Prepare(); err == nil {
+		t.Fatal("want error for unknown unicode_form")
+	}
+}
+
+func TestClean_Whitespace(t *testing.T) {
+	opts := Options{UnicodeForm: "none", SpaceToASCII: true, CollapseSpaces: true}
+	got := Clean("山田　　太郎\t 様 ", opts)
+	want := "山田 太郎 様"
+	if got != want {
+		t.Fatalf("want %q got %q", want, got)
+	}
+
+	// 1 つだけの空白は space_to_ascii がなければそのまま
+	opts = Options{UnicodeForm: "none", CollapseSpaces: true}
+	got = Clean("山田　太郎\t様 　 です", opts)
+	want = "山田　太郎\t様 です"
+	if got != want {
+		t.Fatalf("want %q got %q", want, got)
+	}
+
+	opts = Options{TrimHyphens: true, TrimUnderscores: true, TrimChars: "*"}
+	got = Clean(" -*_コーヒー-ゼリー_ - ", opts)
+	want = "コーヒー-ゼリー"
+	if got != want {
+		t.Fatalf("want %q got %q", want, got)
+	}
+}
+
+func TestClean_HTMLTokenizer(t *testing.T) {
+	opts := Options{UnicodeForm: "none", RemoveHTML: true, HTMLMode: "tokenizer", DecodeHTMLEntities: true}
+	cases := map[string]string{
+		"a < b > c":                             "a < b > c",
+		"<p>A&amp;B</p><!-- memo -->":           "A&B",
+		"x<script>alert('<b>')</script>y":       "xy",
+		"<style>p{color:red}</style>本文&#12354;": "本文あ",
+		"<![CDATA[x<y]]>":                       "x<y",
+		"&lt;b&gt;太字&lt;/b&gt;":                 "<b>太字</b>",
+	}
+	for in, want := range cases {
+		if got := Clean(in, opts); got != want {
+			t.Fatalf("%q: want %q got %q", in, want, got)
+		}
+	}
+
+	// 既定の regex 方式は < から > までを丸ごと消す
+	if got := Clean("a < b > c", Options{RemoveHTML: true}); got != "a  c" {
+		t.Fatalf("regex: got %q", got)
+	}
+}
+
+func TestClean_Emoji(t *testing.T) {
+	opts := Options{RemoveEmoji: true}
+	cases := map[string]string{
+		"☎ 03-1234 ✓": "☎ 03-1234 ✓", // 文字表示の記号は残す
+		"☎\uFE0F 03":  "03",
+		"OK👍🏽!":       "OK!",
+		"家族👨\u200D👩\u200D👧\u200D👦です": "家族です", // ZWJ 連結はまとめて消え、ZWJ が残らない
+		"🇯🇵日本":           "日本",
+		"1\uFE0F\u20E3位": "位",
+		"🫨新しい絵文字":        "新しい絵文字",
+	}
+	for in, want := range cases {
+		if got := Clean(in, opts); got != want {
+			t.Fatalf("%q: want %q got %q", in, want, got)
+		}
+	}
+
+	opts = Options{RemoveEmoji: true, EmojiMode: "name"}
+	if got := Clean("祝🎉👍🏽🫨👍\u200D🎉", opts); got != "祝:party popper::thumbs up: medium skin tone::shaking face:[emoji]" {
+		t.Fatalf("name: got %q", got)
+	}
+	opts = Options{RemoveEmoji: true, EmojiMode: "replace", EmojiPlaceholder: "〓"}
+	if got := Clean("A😀B🇯🇵C", opts); got != "A〓B〓C" {
+		t.Fatalf("replace: got %q", got)
+	}
+
+	// remove_non_printable と併用しても ZWJ・タグ文字が先に消えて絵文字が分かれない
+	opts = Options{RemoveEmoji: true, EmojiMode: "name", RemoveNonPrintable: true}
+	cases = map[string]string{
+		"👨\u200D👩\u200D👧\u200D👦": ":family: man, woman, girl, boy:",
+		"👨\u200D👩\u200D👧":        ":family: man, woman, girl:",
+		"🫱🏻\u200D🫲🏼":             ":handshake: light skin tone, medium-light skin tone:",
+		"👍\u200D🎉":               "[emoji]", // 名前表にない連結も 1 つ
+		"A\U0001F3F4\U000E0067\U000E0062\U000E0065\U000E006E\U000E0067\U000E007FB": "A:flag: England:B", // タグ列の旗
+		"A\u200BB": "AB",
+	}
+	for in, want := range cases {
+		if got := Clean(in, opts); got != want {
+			t.Fatalf("with remove_non_printable %q: want %q got %q", in, want, got)
+		}
+	}
+
+	for _, o := range []Options{{EmojiMode: "delete"}, {HTMLMode: "dom"}} {
+		if err := o.Prepare(); err == nil {
+			t.Fatalf("want error for %+v", o)
+		}
+	}
+}
+
+func TestClean_Numbers(t *testing.T) {
+	opts := Options{UnicodeForm: "none", NormalizeNumbers: true}
+	cases := map[string]string{
+		"二千二十五年":     "2025年",
+		"十二月":        "12月",
+		"二〇二五":       "2025",
+		"三百五十万円":     "3500000円",
+		"1万2000人":    "12000人",
+		"3万5千":       "35000",
+		"⑩番と㊿番":      "10番と50番",
+		"１，２３４，５６７円": "1234567円",
+		"1,234.5":    "1234.5",
+		"1,2,3":      "1,2,3", // 3 桁区切りでないものは残す
+		"３．１４":       "3.14",
+		"第3四半期":      "第3四半期",
+		"万一":         "万一",
+		"1.5万":       "1.5万",
+		"壱万弐千参百拾円":   "12310円",
+		"百":          "100",
+		"第三回":        "第3回",
+		"一つ":         "1つ",
+		"令和六年四月一日":   "令和6年4月1日",
+		"2-三":        "2-3",
+		"⑳㉑㊿":        "20 21 50", // 隣り合う丸数字はつなげない
+		"①2":         "1 2",
+		// 語の一部の漢数字はそのまま
+		"千代田区": "千代田区",
+		"統一":   "統一",
+		"一般的":  "一般的",
+		"第三者":  "第三者",
+		"一郎":   "一郎",
+		"九州":   "九州",
+		"十字路":  "十字路",
+		"一":    "一",
+		"五十嵐":  "五十嵐",
+		"四日市市": "四日市市",
+		"六本木":  "六本木",
+		"二本松市": "二本松市",
+		"十日町":  "十日町",
+		"九十九里": "九十九里",
+		"八百屋":  "八百屋",
+		"七五三":  "七五三",
+		"三条":   "三条",
+		"五月雨":  "五月雨",
+		"三十日":  "30日",
+	}
+	for in, want := range cases {
+		if got := Clean(in, opts); got != want {
+			t.Fatalf("%q: want %q got %q", in, want, got)
+		}
+	}
+
+	// paren_num_to_half も ⑩ 以降を扱う
+	if got := Clean("⑫と㉑", Options{UnicodeForm: "none", ParenNumToHalf: true}); got != "12と21" {
+		t.Fatalf("paren_num_to_half: got %q", got)
+	}
+}
+
+func TestClean_StripDiacritics(t *testing.T) {
+	opts := Options{StripDiacritics: true}
+	cases := map[string]string{
+		"Müller":     "Muller",
+		"José":       "Jose",
+		"Dvořák":     "Dvorak",
+		"Jose\u0301": "Jose", // 分解済みの結合文字
+		"Straße":     "Strasse",
+		"Æsir Søren": "AEsir Soren",
+		"Ελλάδα":     "Ελλαδα",
+		"ガッコウ と パン":  "ガッコウ と パン",
+		"か\u3099":    "が", // NFKC で合成される
+		"髙橋 Łukasz":  "髙橋 Lukasz",
+		"Ёлка":       "Елка",
+	}
+	for in, want := range cases {
+		if got := Clean(in, opts); got != want {
+			t.Fatalf("%q: want %q got %q", in, want, got)
+		}
+	}
+
+	// NFKC を通さなくても、かなに付いた結合文字は残す
+	opts = Options{UnicodeForm: "none", StripDiacritics: true, DiacriticsScripts: []string{"latin"}}
+	if err := opts.Prepare(); err != nil {
+		t.Fatal(err)
+	}
+	if got := Clean("か\u3099 Café Ёлка", opts); got != "か\u3099 Cafe Ёлка" {
+		t.Fatalf("latin only: got %q", got)
+	}
+	opts.DiacriticsScripts = []string{"arabic"}
+	if err := opts.Prepare(); err == nil {
+		t.Fatal("unknown script: want error")
+	}
+}
+
+func TestCleanValue_Date(t *testing.T) {
+	opts := Options{Type: "date"}
+	if err := opts.Prepare(); err != nil {
+		t.Fatal(err)
+	}
+	cases := map[string]string{
+		"令和6年4月1日":    "2024-04-01",
+		"令和六年四月一日":    "2024-04-01",
+		"R6.4.1":      "2024-04-01",
+		"H31/4/30":    "2019-04-30",
+		"平成元年1月8日":    "1989-01-08",
+		"㋿元年5月1日":     "2019-05-01",
+		"2024年4月1日":   "2024-04-01",
+		"2024/4/1(月)": "2024-04-01",
+		"２０２４－０４－０１":  "2024-04-01",
+		"20240401":    "2024-04-01",
+		"":            "",
+	}
+	for in, want := range cases {
+		got, err := CleanValue(in, opts)
+		if err != nil || got != want {
+			t.Fatalf("%q: want %q got %q (%v)", in, want, got, err)
+		}
+	}
+
+	opts.DateLayout = "2006年1月2日"
+	if got, _ := CleanValue("R6.4.1", opts); got != "2024年4月1日" {
+		t.Fatalf("layout: got %q", got)
+	}
+
+	// 解釈できない値: keep は元の値、blank は空文字（どちらも *ValueError を返す）
+	for _, in := range []string{"H31/5/1", "2024/2/30", "来週", "平成0年1月1日"} {
+		got, err := CleanValue(in, Options{Type: "date"})
+		var ve *ValueError
+		if !errors.As(err, &ve) || got != in {
+			t.Fatalf("%q keep: got %q (%v)", in, got, err)
+		}
+		if got, _ := CleanValue(in, Options{Type: "date", OnError: "blank"}); got != "" {
+			t.Fatalf("%q blank: got %q", in, got)
+		}
+	}
+
+	if err := (&Options{Type: "datetime"}).Prepare(); err == nil {
+		t.Fatal("want error for unknown type")
+	}
+}
+
+func TestCleanValue_Address(t *testing.T) {
+	opts := Options{Type: "address"}
+	if err := opts.Prepare(); err != nil {
+		t.Fatal(err)
+	}
+	cases := map[string]string{
+		"東京都千代田区丸の内1丁目2番3号":        "東京都千代田区丸の内1-2-3",
+		"東京都千代田区丸の内一丁目２−３":         "東京都千代田区丸の内1-2-3",
+		"東京都 千代田区 丸の内 1-2-3":       "東京都千代田区丸の内1-2-3",
+		"千代田区丸の内1ー2ー3":             "千代田区丸の内1-2-3",
+		"三鷹市下連雀三丁目十番地の五":           "三鷹市下連雀3-10-5",
+		"港区麻布十番1-2-3":              "港区麻布十番1-2-3",
+		"千代田区一番町5番地":               "千代田区一番町5",
+		"札幌市中央区北1条西2丁目":            "札幌市中央区北1条西2",
+		"新宿区西新宿2-8-1 ABC ビル 201号室": "新宿区西新宿2-8-1 ABCビル201号室",
+	}
+	for in, want := range cases {
+		if got, err := CleanValue(in, opts); err != nil || got != want {
+			t.Fatalf("%q: want %q got %q (%v)", in, want, got, err)
+		}
+	}
+
+	opts.AddressPrefecture = "add"
+	if err := opts.Prepare(); err != nil {
+		t.Fatal(err)
+	}
+	added := map[string]string{
+		"横浜市西区みなとみらい二丁目3番1号": "神奈川県横浜市西区みなとみらい2-3-1",
+		"四日市市諏訪町1番5号":        "三重県四日市市諏訪町1-5",
+		"東村山市本町1-2-3":        "東京都東村山市本町1-2-3",
+		"港北区新横浜2-5-10":       "神奈川県港北区新横浜2-5-10",
+		"虻田郡倶知安町北1条東2丁目":     "北海道虻田郡倶知安町北1条東2",
+	}
+	for in, want := range added {
+		if got, err := CleanValue(in, opts); err != nil || got != want {
+			t.Fatalf("add %q: want %q got %q (%v)", in, want, got, err)
+		}
+	}
+	// 同名の市区町村が複数の都道府県にあるもの（府中市: 東京都・広島県）は判定しない
+	var ve *ValueError
+	for _, in := range []string{"架空市1-2-3", "府中市宮西町2-24", "中央区銀座1-1"} {
+		if got, err := CleanValue(in, opts); !errors.As(err, &ve) || got != in {
+			t.Fatalf("add unknown %q: got %q (%v)", in, got, err)
+		}
+	}
+
+	opts.AddressPrefecture = "strip"
+	if got, _ := CleanValue("東京都新宿区西新宿２丁目８−１", opts); got != "新宿区西新宿2-8-1" {
+		t.Fatalf("strip: got %q", got)
+	}
+}
+
+func TestCleanValue_CompanyName(t *testing.T) {
+	opts := Options{Type: "company_name", RemoveParens: true}
+	cases := map[string]string{
+		"株式会社ABC":              "株式会社ABC",
+		"㈱ABC":                 "株式会社ABC",
+		"（株）ABC":               "株式会社ABC",
+		"ABC (株)":              "ABC株式会社",
+		"ABC KK":               "ABC株式会社",
+		"ABC有限会社":              "ABC有限会社",
+		"(一社)日本ABC協会":          "一般社団法人日本ABC協会",
+		"Acme co.,ltd":         "Acme Co., Ltd.",
+		"Acme Company Limited": "Acme Co., Ltd.",
+		"Acme, Inc":            "Acme Inc.",
+		"Acme Corporation":     "Acme Corp.",
+		"Mainc":                "Mainc",
+		"株式会社":                 "株式会社",
+	}
+	for in, want := range cases {
+		if got, _ := CleanValue(in, opts); got != want {
+			t.Fatalf("%q: want %q got %q", in, want, got)
+		}
+	}
+
+	opts.CompanyLegalForm = "strip"
+	for _, in := range []string{"株式会社ABC", "ABC(株)", "㈱ABC", "ABC Co., Ltd."} {
+		if got, _ := CleanValue(in, opts); got != "ABC" {
+			t.Fatalf("strip %q: got %q", in, got)
+		}
+	}
+	if got, _ := CleanValue("(株)", opts); got != "" {
+		t.Fatalf("strip legal form only: got %q", got)
+	}
+	var ve *ValueError
+	if _, err := CleanValue("(株)", Options{Type: "company_name"}); !errors.As(err, &ve) {
+		t.Fatalf("legal form only: want ValueError, got %v", err)
+	}
+}
+
+func TestCleanValue_Contact(t *testing.T) {
+	cases := []struct {
+		opts Options
+		in   string
+		want string
+	}{
+		{Options{Type: "phone"}, "03-1234-5678", "0312345678"},
+		{Options{Type: "phone"}, "+81 (0)3 1234 5678", "0312345678"},
+		{Options{Type: "phone"}, "TEL:０９０－１２３４－５６７８", "09012345678"},
+		{Options{Type: "phone", PhoneFormat: "hyphen"}, "+81-90-1234-5678", "090-1234-5678"},
+		{Options{Type: "phone", PhoneFormat: "hyphen"}, "(045)123-4567", "045-123-4567"},
+		{Options{Type: "phone", PhoneFormat: "hyphen"}, "0120123456", "0120-123-456"},
+		{Options{Type: "phone", PhoneFormat: "e164"}, "03-1234-5678", "+81312345678"},
+		{Options{Type: "postal_code"}, "〒123-4567", "1234567"},
+		{Options{Type: "postal_code", PostalCodeFormat: "hyphen"}, "１２３４５６７", "123-4567"},
+		{Options{Type: "email"}, " <Taro.Yamada@Example.CO.JP> ", "Taro.Yamada@example.co.jp"},
+		{Options{Type: "email"}, "Taro.Yamada+news@Gmail.com", "Taro.Yamada+news@gmail.com"},
+		{Options{Type: "email", EmailFoldGmail: true}, "Taro.Yamada+news@GoogleMail.com", "taroyamada@gmail.com"},
+	}
+	for _, c := range cases {
+		if got, err := CleanValue(c.in, c.opts); err != nil || got != c.want {
+			t.Fatalf("%s %q: want %q got %q (%v)", c.opts.Type, c.in, c.want, got, err)
+		}
+	}
+
+	for typ, in := range map[string]string{"phone": "090-1234-567", "postal_code": "123-456", "email": "taro@"} {
+		var ve *ValueError
+		if _, err := CleanValue(in, Options{Type: typ}); !errors.As(err, &ve) {
+			t.Fatalf("%s %q: want ValueError, got %v", typ, in, err)
+		}
+	}
+}
+
+func TestCleanValue_URL(t *testing.T) {
+	opts := Options{Type: "url"}
+	cases := map[string]string{
+		"HTTP://Example.COM":                        "http://example.com/",
+		"https://example.com:443/a/./b/../c/":       "https://example.com/a/c/",
+		"http://example.com:8080/%7euser/%e3%81%82": "http://example.com:8080/~user/%E3%81%82",
+		"www.example.com/path?q=1":                  "http://www.example.com/path?q=1",
+		"mailto:Taro@Example.com":                   "mailto:Taro@Example.com",
+	}
+	for in, want := range cases {
+		if got, err := CleanValue(in, opts); err != nil || got != want {
+			t.Fatalf("%q: want %q got %q (%v)", in, want, got, err)
+		}
+	}
+
+	opts = Options{
+		Type:                  "url",
+		URLDropParams:         []string{"utm_*", "fbclid"},
+		URLSortQuery:          true,
+		URLStripFragment:      true,
+		URLStripWWW:           true,
+		URLStripTrailingSlash: true,
+	}
+	if err := opts.Prepare(); err != nil {
+		t.Fatal(err)
+	}
+	in := "https://www.example.com/items/?b=2&UTM_Source=x&a=1&fbclid=abc&a=0#top"
+	if got, _ := CleanValue(in, opts); got != "https://example.com/items?a=1&a=0&b=2" {
+		t.Fatalf("rules: got %q", got)
+	}
+
+	for _, in := range []string{"http://exa mple.com", "http://%zz", "not a url"} {
+		var ve *ValueError
+		if _, err := CleanValue(in, Options{Type: "url", UnicodeForm: "none"}); !errors.As(err, &ve) {
+			t.Fatalf("%q: want ValueError, got %v", in, err)
+		}
+	}
+	if err := (&Options{Type: "url", URLDropParams: []string{"utm_["}}).Prepare(); err == nil {
+		t.Fatal("want error for bad pattern")
+	}
+}
@@ -0,0 +1,270 @@
+package normalize
+
+import (
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// 桁区切り（1,234,567 / １，２３４）と全角小数点（3．14）
+var (
+	reGroupedNumber = regexp.MustCompile(`[0-9]+(?:[,，][0-9]+)+`)
+	reFullDecimal   = regexp.MustCompile(`([0-9])．([0-9])`)
+)
+
+// 漢数字の数字（位取りなし）
+var kanjiDigits = map[rune]uint64{
+	'〇': 0, '零': 0,
+	'一': 1, '壱': 1, '壹': 1,
+	'二': 2, '弐': 2, '貳': 2,
+	'三': 3, '参': 3, '參': 3,
+	'四': 4, '五': 5, '六': 6, '七': 7, '八': 8, '九': 9,
+}
+
+// 十・百・千（万未満の位）
+var kanjiSmallUnits = map[rune]uint64{
+	'十': 10, '拾': 10, '百': 100, '千': 1000, '阡': 1000, '仟': 1000,
+}
+
+// 万・億・兆（4 桁ごとの位）
+var kanjiLargeUnits = map[rune]uint64{
+	'万': 1e4, '萬': 1e4, '億': 1e8, '兆': 1e12,
+}
+
+// numeralSuffixes は直前の漢数字を数として読んでよい語（年・円・助数詞）。
+// 本・条は地名（六本木, 三条）に多いため含めない。
+var numeralSuffixes = map[rune]bool{
+	'年': true, '円': true, '時': true, '分': true, '秒': true,
+	'人': true, '名': true, '個': true, '回': true, '件': true, '枚': true,
+	'台': true, '冊': true, '階': true, '歳': true, '才': true, '号': true, '倍': true,
+	'匹': true, '頭': true, '週': true, '巻': true, '章': true,
+	'か': true, 'ヶ': true, 'ケ': true, 'カ': true, 'ヵ': true, 'つ': true,
+}
+
+// weakNumeralSuffixes は後ろに漢数字以外の漢字が続かないときだけ数として読む語（四日市, 十日町, 五月雨 はそのまま）
+var weakNumeralSuffixes = map[rune]bool{'月': true, '日': true}
+
+// CircledNumber は丸数字（⓪ ①–⑳ ㉑–㊿ ❶–⓴ ➀–➓）の値を返す
+func CircledNumber(r rune) (int, bool) {
+	switch {
+	case r == '⓪' || r == '⓿':
+		return 0, true
+	case r >= '①' && r <= '⑳':
+		return int(r-'①') + 1, true
+	case r >= '㉑' && r <= '㉟':
+		return int(r-'㉑') + 21, true
+	case r >= '㊱' && r <= '㊿':
+		return int(r-'㊱') + 36, true
+	case r >= '❶' && r <= '❿':
+		return int(r-'❶') + 1, true
+	case r >= '⓫' && r <= '⓴':
+		return int(r-'⓫') + 11, true
+	case r >= '➀' && r <= '➉':
+		return int(r-'➀') + 1, true
+	case r >= '➊' && r <= '➓':
+		return int(r-'➊') + 1, true
+	}
+	return 0, false
+}
+
+// NormalizeNumbers は数値表記を半角の算用数字にそろえる。
+//   - 丸数字（① ⑩ ㊿）・全角数字 → 半角数字（丸数字が数字と隣り合うときは空白を挟む: ⑳㉑ → "20 21"）
+//   - 桁区切り（1,234 / １，２３４）を除去（3 桁区切りとして正しいものだけ）
+//   - 全角小数点（3．14）→ "."
+//   - 漢数字（二千二十五 → 2025, 十二 → 12, 二〇二五 → 2025, 3万5千 → 35000）
+func NormalizeNumbers(s string) string {
+	var b strings.Builder
+	b.Grow(len(s))
+	prevDigit, prevCircled := false, false
+	for _, r := range s {
+		if IsZenkakuDigit(r) {
+			r = r - '０' + '0'
+		}
+		if n, ok := CircledNumber(r); ok {
+			if prevDigit {
+				b.WriteByte(' ') // ⑳㉑ を 2021 にしない
+			}
+			b.WriteString(strconv.Itoa(n))
+			prevDigit, prevCircled = true, true
+			continue
+		}
+		digit := isASCIIDigit(r)
+		if digit && prevCircled {
+			b.WriteByte(' ')
+		}
+		b.WriteRune(r)
+		prevDigit, prevCircled = digit, false
+	}
+	s = b.String()
+
+	s = reGroupedNumber.ReplaceAllStringFunc(s, func(m string) string {
+		groups := strings.FieldsFunc(m, func(r rune) bool { return r == ',' || r == '，' })
+		if len(groups[0]) > 3 {
+			return m
+		}
+		for _, g := range groups[1:] {
+			if len(g) != 3 {
+				return m
+			}
+		}
+		return strings.Join(groups, "")
+	})
+	s = reFullDecimal.ReplaceAllString(s, "$1.$2")
+
+	return convertKanjiNumerals(s)
+}
+
+func isASCIIDigit(r rune) bool { return r >= '0' && r <= '9' }
+
+func isKanjiNumeral(r rune) bool {
+	if _, ok := kanjiDigits[r]; ok {
+		return true
+	}
+	if _, ok := kanjiSmallUnits[r]; ok {
+		return true
+	}
+	_, ok := kanjiLargeUnits[r]
+	return ok
+}
+
+// convertKanjiNumerals は漢数字の並びを算用数字に置き換える。
+// 算用数字は位（十百千万億兆）と組み合わさる場合だけ取り込む（3万 → 30000。第3四半期 はそのまま）。
+// 万・億・兆で始まる並び（万一）と小数点の直後（1.5万）は変換しない。
+// 漢数字は語の一部のことが多いため、kanjiNumeralInContext で数と読める場合だけ変換する。
+func convertKanjiNumerals(s string) string {
+	rs := []rune(s)
+	var b strings.Builder
+	b.Grow(len(s))
+
+	for i := 0; i < len(rs); {
+		end := scanKanjiNumeral(rs, i)
+		if end > i && !(i >= 2 && rs[i-1] == '.' && isASCIIDigit(rs[i-2])) && kanjiNumeralInContext(rs, i, end) {
+			if n, ok := parseKanjiNumeral(rs[i:end]); ok {
+				b.WriteString(strconv.FormatUint(n, 10))
+				i = end
+				continue
+			}
+		}
+		b.WriteRune(rs[i])
+		i++
+	}
+	return b.String()
+}
+
+// kanjiNumeralInContext は漢数字の並び rs[i:end] を数として読んでよいかを返す。
+// 漢数字は地名・人名（五十嵐, 八百屋, 九十九里, 七五三, 千代田区, 一郎）に多いため、次の場合だけ読む。
+//   - 年・円・助数詞が続く（二千二十五年, 三人, 第三回）。月・日は後ろに漢数字以外の漢字が続かないとき（十二月, 四月一日）
+//   - 算用数字を含む（3万5千）か、区切りを挟んで算用数字と並ぶ（2-三）
+//   - 位を含み、前後が漢字でない（百, 三百五十万）
+//   - 位を含まない 2 文字以上で 〇 を含む（二〇二五。位取りの表記）
+func kanjiNumeralInContext(rs []rune, i, end int) bool {
+	hanAt := func(k int) bool { return k >= 0 && k < len(rs) && unicode.Is(unicode.Han, rs[k]) }
+	if end < len(rs) && numeralSuffixes[rs[end]] {
+		return true
+	}
+	if end < len(rs) && weakNumeralSuffixes[rs[end]] && !(hanAt(end+1) && !isKanjiNumeral(rs[end+1])) {
+		return true
+	}
+	run := rs[i:end]
+	if slices.ContainsFunc(run, isASCIIDigit) {
+		return true
+	}
+	isSep := func(r rune) bool { return strings.ContainsRune("-−‐/:~〜～", r) }
+	if i >= 2 && isSep(rs[i-1]) && isASCIIDigit(rs[i-2]) || end+1 < len(rs) && isSep(rs[end]) && isASCIIDigit(rs[end+1]) {
+		return true
+	}
+	if slices.ContainsFunc(run, isUnit) {
+		return !hanAt(i-1) && !hanAt(end)
+	}
+	return len(run) > 1 && slices.ContainsFunc(run, func(r rune) bool { return r == '〇' || r == '零' })
+}
+
+// scanKanjiNumeral は rs[i:] 先頭の漢数字の並びの終端を返す（並びでなければ i）
+func scanKanjiNumeral(rs []rune, i int) int {
+	if i > 0 && isASCIIDigit(rs[i-1]) {
+		return i // 算用数字の直後からは始めない（連結すると別の数になる）
+	}
+	if i > 0 && isKanjiNumeral(rs[i-1]) {
+		return i // 万一 の 一 など、並びの途中からは始めない
+	}
+	if _, large := kanjiLargeUnits[rs[i]]; large {
+		return i
+	}
+	hasKanji := false
+	j := i
+	for j < len(rs) {
+		r := rs[j]
+		if isKanjiNumeral(r) {
+			hasKanji = true
+			j++
+			continue
+		}
+		if isASCIIDigit(r) {
+			k := j
+			for k < len(rs) && isASCIIDigit(rs[k]) {
+				k++
+			}
+			// 算用数字は位の直前か、万・億・兆の直後（1万2000 の 2000）だけ
+			nextUnit := k < len(rs) && isUnit(rs[k])
+			afterLarge := j > i && isLargeUnit(rs[j-1])
+			if !nextUnit && !afterLarge {
+				break
+			}
+			j = k
+			continue
+		}
+		break
+	}
+	if !hasKanji {
+		return i
+	}
+	return j
+}
+
+func isUnit(r rune) bool {
+	if _, ok := kanjiSmallUnits[r]; ok {
+		return true
+	}
+	return isLargeUnit(r)
+}
+
+func isLargeUnit(r rune) bool {
+	_, ok := kanjiLargeUnits[r]
+	return ok
+}
+
+// parseKanjiNumeral は漢数字（算用数字混じり可）を数値に変換する。
+// 位を含まない並び（二〇二五）は 1 桁ずつ並べた数として読む。
+func parseKanjiNumeral(rs []rune) (uint64, bool) {
+	var total, section uint64
+	var cur uint64
+	hasCur := false
+	for _, r := range rs {
+		if d, ok := kanjiDigits[r]; ok {
+			cur, hasCur = cur*10+d, true
+		} else if isASCIIDigit(r) {
+			cur, hasCur = cur*10+uint64(r-'0'), true
+		} else if u, ok := kanjiSmallUnits[r]; ok {
+			n := uint64(1)
+			if hasCur {
+				n = cur
+			}
+			section += n * u
+			cur, hasCur = 0, false
+		} else if u, ok := kanjiLargeUnits[r]; ok {
+			if hasCur {
+				section += cur
+			}
+			total += section * u
+			section, cur, hasCur = 0, 0, false
+		} else {
+			return 0, false
+		}
+		if cur > 1e15 || section > 1e15 || total > 1e17 {
+			return 0, false
+		}
+	}
+	return total + section + cur, true
+}
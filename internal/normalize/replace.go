@@ -0,0 +1,67 @@
+package normalize
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ReplaceRule は正規表現による置換規則（replacement では $1 / ${name} でキャプチャを参照）
+type ReplaceRule struct {
+	Pattern     string
+	Replacement string
+	Flags       string // i(大小無視) m(複数行) s(. が改行にも一致) U(最短一致) の組合せ
+}
+
+// Compile は Flags を反映して Pattern をコンパイルする
+func (r ReplaceRule) Compile() (*regexp.Regexp, error) {
+	if r.Pattern == "" {
+		return nil, fmt.Errorf("replace: empty pattern")
+	}
+	for _, f := range r.Flags {
+		if !strings.ContainsRune("imsU", f) {
+			return nil, fmt.Errorf("replace %q: unsupported flag %q (use i, m, s, U)", r.Pattern, f)
+		}
+	}
+	pat := r.Pattern
+	if r.Flags != "" {
+		pat = "(?" + r.Flags + ")" + pat
+	}
+	re, err := regexp.Compile(pat)
+	if err != nil {
+		return nil, fmt.Errorf("replace %q: %w", r.Pattern, err)
+	}
+	return re, nil
+}
+
+func compileReplaceRules(rules []ReplaceRule) ([]*regexp.Regexp, error) {
+	if len(rules) == 0 {
+		return nil, nil
+	}
+	res := make([]*regexp.Regexp, 0, len(rules))
+	for _, r := range rules {
+		re, err := r.Compile()
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, re)
+	}
+	return res, nil
+}
+
+func applyReplace(s string, o *Options) string {
+	res := o.replaceRes
+	if res == nil {
+		// Prepare 未実行時はその場でコンパイル（不正な規則は飛ばす）
+		for _, r := range o.Replace {
+			if re, err := r.Compile(); err == nil {
+				s = re.ReplaceAllString(s, r.Replacement)
+			}
+		}
+		return s
+	}
+	for i, re := range res {
+		s = re.ReplaceAllString(s, o.Replace[i].Replacement)
+	}
+	return s
+}
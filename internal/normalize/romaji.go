@@ -0,0 +1,281 @@
+package normalize
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// カタカナ → ローマ字（ヘボン式。訓令式で異なるものは kunreiRomaji で上書き）
+var hepburnRomaji = map[string]string{
+	"ア": "a", "イ": "i", "ウ": "u", "エ": "e", "オ": "o",
+	"カ": "ka", "キ": "ki", "ク": "ku", "ケ": "ke", "コ": "ko",
+	"ガ": "ga", "ギ": "gi", "グ": "gu", "ゲ": "ge", "ゴ": "go",
+	"サ": "sa", "シ": "shi", "ス": "su", "セ": "se", "ソ": "so",
+	"ザ": "za", "ジ": "ji", "ズ": "zu", "ゼ": "ze", "ゾ": "zo",
+	"タ": "ta", "チ": "chi", "ツ": "tsu", "テ": "te", "ト": "to",
+	"ダ": "da", "ヂ": "ji", "ヅ": "zu", "デ": "de", "ド": "do",
+	"ナ": "na", "ニ": "ni", "ヌ": "nu", "ネ": "ne", "ノ": "no",
+	"ハ": "ha", "ヒ": "hi", "フ": "fu", "ヘ": "he", "ホ": "ho",
+	"バ": "ba", "ビ": "bi", "ブ": "bu", "ベ": "be", "ボ": "bo",
+	"パ": "pa", "ピ": "pi", "プ": "pu", "ペ": "pe", "ポ": "po",
+	"マ": "ma", "ミ": "mi", "ム": "mu", "メ": "me", "モ": "mo",
+	"ヤ": "ya", "ユ": "yu", "ヨ": "yo",
+	"ラ": "ra", "リ": "ri", "ル": "ru", "レ": "re", "ロ": "ro",
+	"ワ": "wa", "ヰ": "i", "ヱ": "e", "ヲ": "o", "ン": "n", "ヴ": "vu",
+	"ァ": "a", "ィ": "i", "ゥ": "u", "ェ": "e", "ォ": "o",
+	"ャ": "ya", "ュ": "yu", "ョ": "yo", "ヮ": "wa", "ヵ": "ka", "ヶ": "ke",
+
+	"シャ": "sha", "シュ": "shu", "ショ": "sho", "シェ": "she",
+	"ジャ": "ja", "ジュ": "ju", "ジョ": "jo", "ジェ": "je",
+	"チャ": "cha", "チュ": "chu", "チョ": "cho", "チェ": "che",
+	"ヂャ": "ja", "ヂュ": "ju", "ヂョ": "jo",
+	"ファ": "fa", "フィ": "fi", "フェ": "fe", "フォ": "fo", "フュ": "fyu",
+	"ヴァ": "va", "ヴィ": "vi", "ヴェ": "ve", "ヴォ": "vo",
+	"ウィ": "wi", "ウェ": "we", "ウォ": "wo", "イェ": "ye",
+	"ティ": "ti", "ディ": "di", "トゥ": "tu", "ドゥ": "du", "テュ": "tyu", "デュ": "dyu",
+	"ツァ": "tsa", "ツィ": "tsi", "ツェ": "tse", "ツォ": "tso",
+	"クァ": "kwa", "グァ": "gwa",
+}
+
+var kunreiRomaji = map[string]string{
+	"シ": "si", "ジ": "zi", "チ": "ti", "ツ": "tu", "ヂ": "zi", "ヅ": "zu", "フ": "hu",
+	"シャ": "sya", "シュ": "syu", "ショ": "syo", "シェ": "sye",
+	"ジャ": "zya", "ジュ": "zyu", "ジョ": "zyo", "ジェ": "zye",
+	"チャ": "tya", "チュ": "tyu", "チョ": "tyo", "チェ": "tye",
+	"ヂャ": "zya", "ヂュ": "zyu", "ヂョ": "zyo",
+}
+
+// 拗音（キャ・ニュ 等）は子音＋ya/yu/yo で組み立てる
+var yoonConsonants = map[string]string{
+	"キ": "k", "ギ": "g", "ニ": "n", "ヒ": "h", "ビ": "b", "ピ": "p", "ミ": "m", "リ": "r",
+}
+
+// ローマ字 → カタカナ（ヘボン式・訓令式・日本式を受け付ける）。
+// 日本語の綴りに現れない l・c（ch 以外）・q・x は載せない（Hello を ヘッロ と読まないため）。
+var romajiKana = map[string]string{}
+
+// sokuonConsonants は重ねて促音（ッ）になる子音。rr・ll・mm・ww のような重ねは日本語の綴りにない
+const sokuonConsonants = "kstpgzdbhfj"
+
+var macronVowels = map[rune]rune{'a': 'ā', 'i': 'ī', 'u': 'ū', 'e': 'ē', 'o': 'ō'}
+
+// 長音記号付きの母音（マクロン・サーカムフレックス）
+var longVowels = map[rune]rune{
+	'ā': 'a', 'ī': 'i', 'ū': 'u', 'ē': 'e', 'ō': 'o',
+	'â': 'a', 'î': 'i', 'û': 'u', 'ê': 'e', 'ô': 'o',
+}
+
+func init() {
+	for base, c := range yoonConsonants {
+		hepburnRomaji[base+"ャ"] = c + "ya"
+		hepburnRomaji[base+"ュ"] = c + "yu"
+		hepburnRomaji[base+"ョ"] = c + "yo"
+	}
+	for _, table := range []map[string]string{kunreiRomaji, hepburnRomaji} {
+		for kana, roma := range table {
+			if _, small := smallKana[[]rune(kana)[0]]; small && utf8.RuneCountInString(kana) == 1 {
+				continue // 小書き仮名は単独では引かない
+			}
+			if _, dup := romajiKana[roma]; !dup {
+				romajiKana[roma] = kana
+			}
+		}
+	}
+	// 表の逆引きで衝突するもの・表にない綴り
+	for roma, kana := range map[string]string{
+		"i": "イ", "e": "エ", "o": "オ", "ji": "ジ", "zu": "ズ", "ja": "ジャ", "ju": "ジュ", "jo": "ジョ",
+		"zya": "ジャ", "zyu": "ジュ", "zyo": "ジョ", "zi": "ジ", "wo": "ヲ",
+		"di": "ヂ", "du": "ヅ", "dya": "ヂャ", "dyu": "ヂュ", "dyo": "ヂョ",
+		"jya": "ジャ", "jyu": "ジュ", "jyo": "ジョ",
+	} {
+		romajiKana[roma] = kana
+	}
+}
+
+// KanaToRomaji はかな（ひらがな・カタカナ。半角カタカナを含む）をローマ字（小文字）にする。かな以外はそのまま。
+//   - system: hepburn(既定: shi, chi, tsu, fu, ja) | kunrei(si, ti, tu, hu, zya)
+//   - longVowel: omit(既定: トウキョウ → tokyo) | macron(tōkyō) | spelled(toukyou。ー は直前の母音を重ねる)
+//
+// omit / macron が長音とみなすのは オウ・オオ・ウウ と ー（エイ・イイ は綴りどおり）。
+// ン は常に n（shinichi。アポストロフィや m は付けない）。
+func KanaToRomaji(s, system, longVowel string) string {
+	kunrei := strings.EqualFold(system, "kunrei")
+	longVowel = strings.ToLower(longVowel)
+	rs := []rune(HiraganaToKatakana(s))
+	out := make([]rune, 0, len(rs)*2)
+	prevKana := false // 直前の出力がかなから変換したものか
+	sokuon := false   // ッ の直後
+	for i := 0; i < len(rs); i++ {
+		r := rs[i]
+		if r == 'ー' && prevKana {
+			if n := len(out); isRomajiVowel(out[n-1]) {
+				switch longVowel {
+				case "spelled":
+					out = append(out, out[n-1])
+				case "macron":
+					out[n-1] = macronVowels[out[n-1]]
+				}
+			}
+			continue
+		}
+		if r == 'ッ' {
+			sokuon = true
+			continue
+		}
+		roma, width := lookupRomaji(rs[i:], kunrei)
+		if roma == "" {
+			sokuon = false
+			prevKana = false
+			out = append(out, r)
+			continue
+		}
+		i += width - 1
+
+		if n := len(out); prevKana && n > 0 && !sokuon &&
+			(roma == "u" && (out[n-1] == 'o' || out[n-1] == 'u') || roma == "o" && out[n-1] == 'o') {
+			switch longVowel {
+			case "spelled":
+				out = append(out, []rune(roma)...)
+			case "macron":
+				out[n-1] = macronVowels[out[n-1]]
+			}
+			continue
+		}
+		if sokuon && !isRomajiVowel(rune(roma[0])) && roma != "n" {
+			if strings.HasPrefix(roma, "ch") {
+				out = append(out, 't')
+			} else {
+				out = append(out, rune(roma[0]))
+			}
+		}
+		sokuon = false
+		prevKana = true
+		out = append(out, []rune(roma)...)
+	}
+	return string(out)
+}
+
+func lookupRomaji(rs []rune, kunrei bool) (string, int) {
+	for width := 2; width >= 1; width-- {
+		if len(rs) < width {
+			continue
+		}
+		kana := string(rs[:width])
+		if kunrei {
+			if roma, ok := kunreiRomaji[kana]; ok {
+				return roma, width
+			}
+		}
+		if roma, ok := hepburnRomaji[kana]; ok {
+			return roma, width
+		}
+	}
+	return "", 0
+}
+
+func isRomajiVowel(r rune) bool {
+	return strings.ContainsRune("aiueo", r)
+}
+
+// RomajiToKana はローマ字の語をカタカナにする。語（英字の連続）全体をローマ字として読めたときだけ変換し、
+// 読めない語（Corp, ABC など）はそのまま残す。大小文字は区別しない。
+//   - ヘボン式・訓令式・日本式の綴り（shi/si, tsu/tu, ja/zya/jya）を受け付ける
+//   - 長音: ō/ô → オー、oh（母音の前以外）→ オー。ou/oo は綴りどおり オウ/オオ
+//   - 撥音: n（母音・y の前以外）、n'、m（b/m/p の前）→ ン。促音: 子音の重ね（kk, tch）→ ッ
+//
+// 英語の語でも綴りがローマ字として読めれば変換されるため（Made → マデ）、人名などの列にだけ使う。
+func RomajiToKana(s string) string {
+	var b strings.Builder
+	b.Grow(len(s) * 2)
+	rs := []rune(s)
+	for i := 0; i < len(rs); {
+		if !isRomajiLetter(rs[i]) {
+			b.WriteRune(rs[i])
+			i++
+			continue
+		}
+		j := i
+		for j < len(rs) && (isRomajiLetter(rs[j]) || rs[j] == '\'' && j > i) {
+			j++
+		}
+		word := rs[i:j]
+		if kana, ok := wordToKana([]rune(strings.ToLower(string(word)))); ok {
+			b.WriteString(kana)
+		} else {
+			b.WriteString(string(word))
+		}
+		i = j
+	}
+	return b.String()
+}
+
+func wordToKana(word []rune) (string, bool) {
+	// 長音付きの母音は 母音＋ー に展開しておく（tōkyō → to ー kyo ー）
+	w := make([]rune, 0, len(word)+2)
+	for _, r := range word {
+		if v, ok := longVowels[r]; ok {
+			w = append(w, v, 'ー')
+		} else {
+			w = append(w, r)
+		}
+	}
+	var b strings.Builder
+	vowelAt := func(i int) bool { return i < len(w) && isRomajiVowel(w[i]) }
+	prevO := false
+	for i := 0; i < len(w); {
+		c := w[i]
+		if c == 'ー' {
+			b.WriteRune(c)
+			prevO = false
+			i++
+			continue
+		}
+		switch {
+		case c == 'n' && i+1 < len(w) && w[i+1] == '\'':
+			b.WriteString("ン")
+			i += 2
+			prevO = false
+			continue
+		// 母音・y の前の n は常にナ行として読む。Shinichi はシニチ、Kenichi はケニチになる
+		// （シンイチ と読ませるには Shin'ichi と書く）。綴りだけでは区別できないため補わない
+		case c == 'n' && !vowelAt(i+1) && (i+1 >= len(w) || w[i+1] != 'y'),
+			c == 'm' && i+1 < len(w) && strings.ContainsRune("bmp", w[i+1]):
+			b.WriteString("ン")
+			i++
+			prevO = false
+			continue
+		case c == 'h' && prevO && !vowelAt(i+1) && (i+1 >= len(w) || w[i+1] != 'y'):
+			b.WriteString("ー") // Ohno, Saitoh
+			i++
+			prevO = false
+			continue
+		case strings.ContainsRune(sokuonConsonants, c) && i+1 < len(w) && (w[i+1] == c || c == 't' && w[i+1] == 'c'):
+			b.WriteString("ッ")
+			i++
+			prevO = false
+			continue
+		}
+		matched := false
+		for width := 3; width >= 1; width-- {
+			if i+width > len(w) {
+				continue
+			}
+			if kana, ok := romajiKana[string(w[i:i+width])]; ok {
+				b.WriteString(kana)
+				prevO = w[i+width-1] == 'o'
+				i += width
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return "", false
+		}
+	}
+	return b.String(), true
+}
+
+func isRomajiLetter(r rune) bool {
+	return 'a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' || longVowels[unicode.ToLower(r)] != 0
+}
@@ -0,0 +1,67 @@
+package normalize
+
+import (
+	"strings"
+	"unicode"
+)
+
+// trim_hyphens で前後から取り除く文字（長音 ー は語の一部なので含めない）
+const hyphenChars = "-‐‑‒–—―−－﹣"
+
+// trim_underscores で前後から取り除く文字
+const underscoreChars = "_＿"
+
+// SpaceToASCII は Unicode の空白（Zs: 全角空白・NBSP・EN SPACE など）を半角空白にする
+func SpaceToASCII(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.Is(unicode.Zs, r) {
+			return ' '
+		}
+		return r
+	}, s)
+}
+
+// CollapseSpaces は 2 つ以上続く空白（Zs とタブ）を半角空白 1 つにまとめる。改行はそのまま。
+// 1 つだけの空白は種類を変えない（全角空白の半角化は space_to_ascii で行う）。
+func CollapseSpaces(s string) string {
+	isSpace := func(r rune) bool { return r == '\t' || unicode.Is(unicode.Zs, r) }
+	var b strings.Builder
+	b.Grow(len(s))
+	rs := []rune(s)
+	for i := 0; i < len(rs); {
+		j := i
+		for j < len(rs) && isSpace(rs[j]) {
+			j++
+		}
+		switch {
+		case j-i >= 2:
+			b.WriteByte(' ')
+		case j-i == 1:
+			b.WriteRune(rs[i])
+		default:
+			b.WriteRune(rs[i])
+			j++
+		}
+		i = j
+	}
+	return b.String()
+}
+
+// trimSet は前後から取り除く文字集合（空白は常に含む）
+func (o *Options) trimSet() string {
+	set := o.TrimChars
+	if o.TrimHyphens {
+		set += hyphenChars
+	}
+	if o.TrimUnderscores {
+		set += underscoreChars
+	}
+	return set
+}
+
+// TrimWithSpaces は前後の空白と set の文字を、どちらも現れなくなるまで取り除く
+func TrimWithSpaces(s, set string) string {
+	return strings.TrimFunc(s, func(r rune) bool {
+		return unicode.IsSpace(r) || strings.ContainsRune(set, r)
+	})
+}
@@ -0,0 +1,220 @@
+package normalize
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/width"
+)
+
+// Step は正規化パイプラインの 1 工程（名前付き・単体で実行可能）。
+type Step struct {
+	Name string
+
+	// enabled は steps 未指定（既定パイプライン）のときに実行するかを Options から判定する。
+	enabled func(o *Options) bool
+	apply   func(s string, o *Options) string
+}
+
+// Apply は工程を 1 回だけ実行する（有効/無効フラグは見ない）。
+func (st Step) Apply(s string, o *Options) string {
+	return st.apply(s, o)
+}
+
+var (
+	registry     = map[string]Step{}
+	defaultOrder []Step
+)
+
+// register は工程を登録する。登録順がそのまま既定パイプラインの順序になる。
+func register(name string, enabled func(o *Options) bool, apply func(s string, o *Options) string) {
+	if _, dup := registry[name]; dup {
+		panic("normalize: duplicate step " + name)
+	}
+	st := Step{Name: name, enabled: enabled, apply: apply}
+	registry[name] = st
+	defaultOrder = append(defaultOrder, st)
+}
+
+// LookupStep は名前から工程を引く。
+func LookupStep(name string) (Step, bool) {
+	st, ok := registry[strings.ToLower(strings.TrimSpace(name))]
+	return st, ok
+}
+
+// StepNames は登録済み工程名を既定パイプラインの順序で返す。
+func StepNames() []string {
+	names := make([]string, 0, len(defaultOrder))
+	for _, st := range defaultOrder {
+		names = append(names, st.Name)
+	}
+	return names
+}
+
+// resolveSteps は steps 指定を工程列に変換する。未知の名前はエラー。
+func resolveSteps(names []string) ([]Step, error) {
+	out := make([]Step, 0, len(names))
+	for _, n := range names {
+		st, ok := LookupStep(n)
+		if !ok {
+			return nil, fmt.Errorf("unknown normalize step: %q (available: %s)", n, strings.Join(StepNames(), ", "))
+		}
+		out = append(out, st)
+	}
+	return out, nil
+}
+
+// 既定順は旧 Clean の固定順を踏襲（重複実行は解消し、部分文字列除去は文字集合削除より先に行う）
+func init() {
+	// 0. Unicode 正規化（既定 NFKC。preserve_chars の文字は対象外）
+	register("unicode_normalize", func(o *Options) bool { return !strings.EqualFold(o.UnicodeForm, "none") },
+		applyUnicodeForm)
+
+	// 1. 幅変換
+	register("half_kana_to_full", func(o *Options) bool { return o.HalfKanaToFull },
+		func(s string, o *Options) string { return transformString(width.Widen, s) })
+	register("full_kana_to_half", func(o *Options) bool { return o.FullKanaToHalf },
+		func(s string, o *Options) string { return transformString(width.Narrow, s) })
+	register("full_digit_to_half", func(o *Options) bool { return o.FullDigitToHalf },
+		func(s string, o *Options) string { return transformString(width.Narrow, s) })
+	register("paren_num_to_half", func(o *Options) bool { return o.ParenNumToHalf },
+		func(s string, o *Options) string { return reParenNum.ReplaceAllStringFunc(s, fullNumToHalf) })
+
+	// 1-0. 数値表記（漢数字・丸数字・桁区切り）
+	register("normalize_numbers", func(o *Options) bool { return o.NormalizeNumbers },
+		func(s string, o *Options) string { return NormalizeNumbers(s) })
+
+	// 1-1. Unicode 空白 → 半角空白（remove_chars: " " 等で一括処理できるよう早めに）
+	register("space_to_ascii", func(o *Options) bool { return o.SpaceToASCII },
+		func(s string, o *Options) string { return SpaceToASCII(s) })
+
+	// 1a. 表記ゆれ辞書（カッコ削除やケース変換より前に、㈱→(株) 等の NFKC 後の表記で引く）
+	register("dictionary", func(o *Options) bool { return len(o.Dictionaries) > 0 }, applyDictionaries)
+
+	// 1b. かな種別・小書き・濁点の畳み込み（照合キーの緩さを調整）
+	// ローマ字 → かな は畳み込みの前に、かな → ローマ字 は畳み込みの後に行う
+	register("romaji_to_kana", func(o *Options) bool { return o.RomajiToKana },
+		func(s string, o *Options) string { return RomajiToKana(s) })
+	register("hiragana_to_katakana", func(o *Options) bool { return o.HiraganaToKatakana },
+		func(s string, o *Options) string { return HiraganaToKatakana(s) })
+	register("katakana_to_hiragana", func(o *Options) bool { return o.KatakanaToHiragana && !o.HiraganaToKatakana },
+		func(s string, o *Options) string { return KatakanaToHiragana(s) })
+	register("fold_small_kana", func(o *Options) bool { return o.FoldSmallKana },
+		func(s string, o *Options) string { return FoldSmallKana(s) })
+	register("strip_dakuten", func(o *Options) bool { return o.StripDakuten },
+		func(s string, o *Options) string { return StripDakuten(s) })
+	register("kana_to_romaji", func(o *Options) bool { return o.KanaToRomaji },
+		func(s string, o *Options) string { return KanaToRomaji(s, o.RomajiSystem, o.RomajiLongVowel) })
+
+	// 1c. 異体字（髙/高, 﨑/崎 など NFKC で統一されないもの）
+	register("fold_kanji_variants", func(o *Options) bool { return o.FoldKanjiVariants }, applyKanjiVariants)
+
+	// 1d. ラテン・ギリシャ・キリル文字のダイアクリティカルマーク（かなの濁点は残す）
+	register("strip_diacritics", func(o *Options) bool { return o.StripDiacritics },
+		func(s string, o *Options) string { return StripDiacritics(s, o.diacriticScripts) })
+
+	// 2. ハイフン
+	register("dash_to_hyphen", func(o *Options) bool { return o.DashToHyphen },
+		func(s string, o *Options) string { return reDash.ReplaceAllString(s, "-") })
+
+	// 3. ケース（両方指定時は to_upper 優先）
+	register("to_upper", func(o *Options) bool { return o.ToUpper },
+		func(s string, o *Options) string { return strings.ToUpper(s) })
+	register("to_lower", func(o *Options) bool { return o.ToLower && !o.ToUpper },
+		func(s string, o *Options) string { return strings.ToLower(s) })
+
+	// 3a. 法人格（type: company_name。(株) がカッコ削除で「株」だけ残らないよう先に処理）
+	register("company_legal_form", func(o *Options) bool { return strings.EqualFold(o.Type, "company_name") },
+		func(s string, o *Options) string {
+			out, _ := NormalizeCompanyName(s, o.CompanyLegalForm)
+			return out
+		})
+
+	// 4. カッコ
+	register("remove_parens", func(o *Options) bool { return o.RemoveParens },
+		func(s string, o *Options) string { return reParens.ReplaceAllString(s, "") })
+
+	// 5-1. 特定タグだけ除去（中身は保持）
+	register("remove_html_tags", func(o *Options) bool { return o.removeTagsRe != nil },
+		func(s string, o *Options) string {
+			if o.removeTagsRe == nil {
+				return s
+			}
+			return o.removeTagsRe.ReplaceAllString(s, "")
+		})
+
+	// 5-2. HTML 全除去（tokenizer ならタグにならない < > を残し、script/style・コメントも除去）
+	register("remove_html", func(o *Options) bool { return o.RemoveHTML },
+		func(s string, o *Options) string {
+			if strings.EqualFold(o.HTMLMode, "tokenizer") {
+				return StripHTML(s)
+			}
+			return reHTMLTag.ReplaceAllString(s, "")
+		})
+
+	// 5-3. 文字参照の復号（タグ除去の後なので &lt;b&gt; はタグとして消えない）
+	register("decode_html_entities", func(o *Options) bool { return o.DecodeHTMLEntities },
+		func(s string, o *Options) string { return DecodeHTMLEntities(s) })
+
+	// 5-4. 絵文字（書記素クラスタ単位。ZWJ・タグ文字（Cf）を非印刷の削除で失う前に行う）
+	register("remove_emoji", func(o *Options) bool { return o.RemoveEmoji }, applyEmoji)
+
+	// 6. 非印刷（制御/書式）
+	register("remove_non_printable", func(o *Options) bool { return o.RemoveNonPrintable },
+		func(s string, o *Options) string { return reNonPrintable.ReplaceAllString(s, "") })
+
+	// 6a. 異体字セレクタ（Mn のため上では消えない）
+	register("remove_ivs", func(o *Options) bool { return o.RemoveIVS },
+		func(s string, o *Options) string { return RemoveIVS(s) })
+
+	// 7. カテゴリ系の削除
+	register("remove_punctuation", func(o *Options) bool { return o.RemovePunctuation },
+		func(s string, o *Options) string {
+			return removeByPredicate(s, func(r rune) bool { return unicode.In(r, unicode.Punct) })
+		})
+	register("remove_symbols", func(o *Options) bool { return o.RemoveSymbols },
+		func(s string, o *Options) string {
+			return removeByPredicate(s, func(r rune) bool { return unicode.In(r, unicode.Symbol) })
+		})
+
+	// 8. 改行だけ削除（CR/LF のみ）
+	register("remove_crlf_only", func(o *Options) bool { return o.RemoveCRLFOnly },
+		func(s string, o *Options) string {
+			return removeByPredicate(s, func(r rune) bool { return r == '\r' || r == '\n' })
+		})
+
+	// 9. 正規表現置換
+	register("replace", func(o *Options) bool { return len(o.Replace) > 0 }, applyReplace)
+
+	// 10. 特定部分文字列のリテラル除去
+	register("remove_substrings", func(o *Options) bool { return len(o.RemoveSubstrings) > 0 },
+		func(s string, o *Options) string {
+			for _, sub := range o.RemoveSubstrings {
+				if sub == "" {
+					continue
+				}
+				s = strings.ReplaceAll(s, sub, "")
+			}
+			return s
+		})
+
+	// 11. 個別文字（集合）削除（配列＋文字列を合算）
+	register("remove_chars", func(o *Options) bool { return o.RemoveChars != "" || len(o.RemoveCharsList) > 0 },
+		func(s string, o *Options) string {
+			var b strings.Builder
+			for _, set := range o.RemoveCharsList {
+				b.WriteString(set)
+			}
+			b.WriteString(o.RemoveChars)
+			return removeChars(s, b.String())
+		})
+
+	// 12. 空白の連続をまとめる
+	register("collapse_spaces", func(o *Options) bool { return o.CollapseSpaces },
+		func(s string, o *Options) string { return CollapseSpaces(s) })
+
+	// 13. 前後の文字（trim_chars / trim_hyphens / trim_underscores）を空白と合わせてトリム
+	register("trim_chars", func(o *Options) bool { return o.trimSet() != "" },
+		func(s string, o *Options) string { return TrimWithSpaces(s, o.trimSet()) })
+}
@@ -0,0 +1,77 @@
+package normalize
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ValueError は列の型（type: date など）として解釈できなかった値
+type ValueError struct {
+	Type   string
+	Value  string // 正規化工程を通した後の値
+	Reason string
+}
+
+func (e *ValueError) Error() string {
+	return fmt.Sprintf("%s: %s: %q", e.Type, e.Reason, e.Value)
+}
+
+// typeParser は正規化済みの値を型の正規形に変換する。
+// エラー時も途中までそろえた値を返してよい（空なら元の値を使う）。
+type typeParser func(s string, o *Options) (string, error)
+
+var typeParsers = map[string]typeParser{}
+
+func registerType(name string, fn typeParser) {
+	if _, dup := typeParsers[name]; dup {
+		panic("normalize: duplicate type " + name)
+	}
+	typeParsers[name] = fn
+}
+
+// TypeNames は登録済みの列の型名を返す
+func TypeNames() []string {
+	names := make([]string, 0, len(typeParsers))
+	for n := range typeParsers {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ValidateType は type / on_error の値を検証する
+func ValidateType(name, onError string) error {
+	if name = strings.ToLower(strings.TrimSpace(name)); name != "" {
+		if _, ok := typeParsers[name]; !ok {
+			return fmt.Errorf("unknown type: %q (available: %s)", name, strings.Join(TypeNames(), ", "))
+		}
+	}
+	switch strings.ToLower(onError) {
+	case "", "keep", "blank", "report":
+	default:
+		return fmt.Errorf("unknown on_error: %q (use keep, blank or report)", onError)
+	}
+	return nil
+}
+
+// CleanValue は Clean の後に type の変換を行う。
+// 解釈できない値は on_error に従って正規化後の値（keep/report）か空文字（blank）を返し、*ValueError も返す。
+func CleanValue(s string, opt Options) (string, error) {
+	s = Clean(s, opt)
+	parse, ok := typeParsers[strings.ToLower(strings.TrimSpace(opt.Type))]
+	if !ok || s == "" {
+		return s, nil
+	}
+	out, err := parse(s, &opt)
+	if err == nil {
+		return out, nil
+	}
+	if strings.EqualFold(opt.OnError, "blank") {
+		return "", err
+	}
+	if out != "" {
+		return out, err // 型の一部だけそろえられた値（address など）
+	}
+	return s, err
+}
@@ -0,0 +1,60 @@
+package normalize
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// unicodeForm は unicode_form の値（空は nfkc）を norm.Form に変換する。none なら ok=false。
+func unicodeForm(name string) (f norm.Form, ok bool, err error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "", "nfkc":
+		return norm.NFKC, true, nil
+	case "nfc":
+		return norm.NFC, true, nil
+	case "nfd":
+		return norm.NFD, true, nil
+	case "nfkd":
+		return norm.NFKD, true, nil
+	case "none":
+		return 0, false, nil
+	default:
+		return 0, false, fmt.Errorf("unknown unicode_form: %q (use nfc, nfd, nfkc, nfkd or none)", name)
+	}
+}
+
+// ValidateUnicodeForm は unicode_form の値を検証する
+func ValidateUnicodeForm(name string) error {
+	_, _, err := unicodeForm(name)
+	return err
+}
+
+// normalizeForm は preserve に含まれる文字を除いた部分だけを f で正規化する
+func normalizeForm(s string, f norm.Form, preserve string) string {
+	if preserve == "" || !strings.ContainsAny(s, preserve) {
+		return f.String(s)
+	}
+	var b strings.Builder
+	b.Grow(len(s))
+	start := 0
+	for i, r := range s {
+		if !strings.ContainsRune(preserve, r) {
+			continue
+		}
+		b.WriteString(f.String(s[start:i]))
+		b.WriteRune(r)
+		start = i + len(string(r))
+	}
+	b.WriteString(f.String(s[start:]))
+	return b.String()
+}
+
+func applyUnicodeForm(s string, o *Options) string {
+	f, ok, err := unicodeForm(o.UnicodeForm)
+	if err != nil || !ok {
+		return s
+	}
+	return normalizeForm(s, f, o.PreserveChars)
+}
@@ -0,0 +1,228 @@
+package normalize
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// URLRules は type: url の正規化ルール
+type URLRules struct {
+	DropParams         []string // 取り除くクエリパラメータ名のパターン（path.Match 形式。utm_* など。大小文字は区別しない）
+	SortQuery          bool     // クエリをパラメータ名順に並べる（同名は元の順）
+	StripFragment      bool     // #以降を取り除く
+	StripWWW           bool     // ホスト先頭の www. を取り除く
+	StripTrailingSlash bool     // パス末尾の / を取り除く（ルートの / は残す）
+}
+
+var reOpaqueScheme = regexp.MustCompile(`(?i)^(?:mailto|tel|urn|data):`)
+
+var defaultPorts = map[string]string{"http": "80", "https": "443", "ftp": "21", "ws": "80", "wss": "443"}
+
+func (o *Options) urlRules() URLRules {
+	return URLRules{
+		DropParams:         o.URLDropParams,
+		SortQuery:          o.URLSortQuery,
+		StripFragment:      o.URLStripFragment,
+		StripWWW:           o.URLStripWWW,
+		StripTrailingSlash: o.URLStripTrailingSlash,
+	}
+}
+
+// validateURLRules はパラメータ名のパターンを検証する
+func validateURLRules(r URLRules) error {
+	for _, p := range r.DropParams {
+		if _, err := path.Match(strings.ToLower(p), ""); err != nil {
+			return fmt.Errorf("url_drop_params: %q: %w", p, err)
+		}
+	}
+	return nil
+}
+
+// CanonicalURL は URL を正規形にする。常に行うのは次のとおり。
+//   - スキーム・ホストを小文字に、既定ポート（http:80, https:443 など）とホスト末尾の . を除去
+//   - パーセントエンコードを統一（非予約文字はデコード、それ以外は大文字の %XX。非 ASCII はエンコード）
+//   - パスの . / .. を解決し、空のパスは /
+//   - スキームのない www.example.com/… は http:// を補う
+//
+// 解釈できない値は *ValueError を返す。
+func CanonicalURL(s string, r URLRules) (string, error) {
+	raw := s
+	s = strings.TrimSpace(s)
+	if strings.ContainsAny(s, " \t\r\n") {
+		return "", &ValueError{Type: "url", Value: raw, Reason: "contains whitespace"}
+	}
+	if !strings.Contains(s, "://") && !reOpaqueScheme.MatchString(s) {
+		s = "http://" + s
+	}
+	u, err := url.Parse(s)
+	if err != nil {
+		return "", &ValueError{Type: "url", Value: raw, Reason: "unparseable"}
+	}
+	scheme := strings.ToLower(u.Scheme)
+	if u.Opaque != "" {
+		return scheme + ":" + u.Opaque, nil // mailto: など
+	}
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	if scheme == "" || host == "" || !strings.Contains(host, ".") && host != "localhost" && !strings.Contains(host, ":") {
+		return "", &ValueError{Type: "url", Value: raw, Reason: "missing scheme or host"}
+	}
+	if r.StripWWW {
+		host = strings.TrimPrefix(host, "www.")
+	}
+	if strings.Contains(host, ":") {
+		host = "[" + host + "]" // IPv6
+	}
+	if port := u.Port(); port != "" && port != defaultPorts[scheme] {
+		host += ":" + port
+	}
+
+	var b strings.Builder
+	b.WriteString(scheme)
+	b.WriteString("://")
+	if u.User != nil {
+		b.WriteString(u.User.String())
+		b.WriteByte('@')
+	}
+	b.WriteString(host)
+	b.WriteString(canonicalPath(u.EscapedPath(), r.StripTrailingSlash))
+	if q := canonicalQuery(u.RawQuery, r); q != "" {
+		b.WriteByte('?')
+		b.WriteString(q)
+	}
+	if !r.StripFragment && u.Fragment != "" {
+		b.WriteByte('#')
+		b.WriteString(normalizePercent(u.EscapedFragment(), ""))
+	}
+	return b.String(), nil
+}
+
+func canonicalPath(p string, stripSlash bool) string {
+	if p == "" {
+		return "/"
+	}
+	trailing := strings.HasSuffix(p, "/")
+	segs := strings.Split(p, "/")
+	out := make([]string, 0, len(segs))
+	for _, seg := range segs[1:] {
+		switch seg {
+		case ".":
+		case "..":
+			if len(out) > 0 {
+				out = out[:len(out)-1]
+			}
+		default:
+			out = append(out, normalizePercent(seg, "/?#"))
+		}
+	}
+	// 末尾の空セグメント（/ で終わる）は join で付け直す
+	for len(out) > 0 && out[len(out)-1] == "" {
+		out = out[:len(out)-1]
+	}
+	p = "/" + strings.Join(out, "/")
+	if trailing && !stripSlash && p != "/" {
+		p += "/"
+	}
+	return p
+}
+
+func canonicalQuery(raw string, r URLRules) string {
+	if raw == "" {
+		return ""
+	}
+	type param struct{ key, name, value string }
+	var params []param
+	for _, kv := range strings.Split(raw, "&") {
+		if kv == "" {
+			continue
+		}
+		k, v, hasValue := strings.Cut(kv, "=")
+		p := param{key: normalizePercent(k, "&=+#")}
+		if hasValue {
+			p.value = "=" + normalizePercent(v, "&+#")
+		}
+		name, err := url.QueryUnescape(k)
+		if err != nil {
+			name = k
+		}
+		p.name = strings.ToLower(name)
+		if matchAny(r.DropParams, p.name) {
+			continue
+		}
+		params = append(params, p)
+	}
+	if r.SortQuery {
+		sort.SliceStable(params, func(i, j int) bool { return params[i].key < params[j].key })
+	}
+	parts := make([]string, len(params))
+	for i, p := range params {
+		parts[i] = p.key + p.value
+	}
+	return strings.Join(parts, "&")
+}
+
+func matchAny(patterns []string, name string) bool {
+	for _, p := range patterns {
+		if ok, _ := path.Match(strings.ToLower(p), name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizePercent は %XX を大文字にそろえ、非予約文字（英数字と -._~）の %XX はデコードする。
+// 非 ASCII・空白・制御文字と reserved に含まれる文字はエンコードする。
+func normalizePercent(s, reserved string) string {
+	const hex = "0123456789ABCDEF"
+	var b strings.Builder
+	b.Grow(len(s))
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c == '%' && i+2 < len(s) && isHex(s[i+1]) && isHex(s[i+2]) {
+			v := unhex(s[i+1])<<4 | unhex(s[i+2])
+			if isUnreserved(v) {
+				b.WriteByte(v)
+			} else {
+				b.WriteByte('%')
+				b.WriteByte(hex[v>>4])
+				b.WriteByte(hex[v&15])
+			}
+			i += 2
+			continue
+		}
+		if c >= 0x80 || c <= ' ' || c == 0x7f || c == '%' || strings.IndexByte(reserved, c) >= 0 {
+			b.WriteByte('%')
+			b.WriteByte(hex[c>>4])
+			b.WriteByte(hex[c&15])
+			continue
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
+
+func isUnreserved(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' ||
+		c == '-' || c == '.' || c == '_' || c == '~'
+}
+
+func isHex(c byte) bool {
+	return '0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F'
+}
+
+func unhex(c byte) byte {
+	switch {
+	case '0' <= c && c <= '9':
+		return c - '0'
+	case 'a' <= c && c <= 'f':
+		return c - 'a' + 10
+	}
+	return c - 'A' + 10
+}
+
+func init() {
+	registerType("url", func(s string, o *Options) (string, error) { return CanonicalURL(s, o.urlRules()) })
+}